@@ -1,6 +1,6 @@
 module github.com/steveteuber/kubectl-graph
 
-go 1.21
+go 1.22.0
 toolchain go1.22.5
 
 require (
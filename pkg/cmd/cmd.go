@@ -1,15 +1,29 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/schollz/progressbar/v3"
 	"github.com/spf13/cobra"
 	"github.com/steveteuber/kubectl-graph/pkg/graph"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"k8s.io/kubectl/pkg/util/templates"
 
@@ -25,6 +39,9 @@ var (
 		# Visualize all pods in graphviz output format.
 		%[1]s graph deployments,replicasets,pods | dot -T svg -o pods.svg
 
+		# Render all pods to a svg image with the dot binary of Graphviz.
+		%[1]s graph deployments,replicasets,pods -o svg --output-file pods.svg
+
 		# Visualize all pods in cypher output format.
 		%[1]s graph deployments,replicasets,pods -o cypher | cypher-shell -u neo4j -p secret
 
@@ -34,6 +51,18 @@ var (
 		# Visualize resources from a directory with kustomization.yaml - e.g. dir/kustomization.yaml.
 		%[1]s graph -k dir/ | dot -T svg -o kustomization.svg
 
+		# Preview the relationships of manifests read from stdin, which may not be applied yet.
+		cat manifests.yaml | %[1]s graph -f - | dot -T svg -o preview.svg
+
+		# Visualize all pods in graphml output format for import into yEd or Gephi.
+		%[1]s graph deployments,replicasets,pods -o graphml --output-file pods.graphml
+
+		# Print all deployments, replicasets and pods as an indented tree in the terminal.
+		%[1]s graph deployments,replicasets,pods -o tree
+
+		# Print all workloads which consume the configmap app-config as an indented tree in the terminal.
+		%[1]s graph consumers configmap/app-config -o tree
+
 		# Visualize all pods and networkpolicies together in graphviz output format.
 		%[1]s graph networkpolicies | dot -T svg -o networkpolicies.svg`)
 )
@@ -41,17 +70,51 @@ var (
 // GraphOptions contains the input to the graph command.
 type GraphOptions struct {
 	configFlags *genericclioptions.ConfigFlags
-
-	AllNamespaces     bool
-	ChunkSize         int64
-	CmdParent         string
-	ExplicitNamespace bool
-	FieldSelector     string
-	LabelSelector     string
-	Namespace         string
-	Namespaces        []string
-	OutputFormat      string
-	Truncate          int
+	timings     *Timings
+	watched     map[schema.GroupVersionResource]bool
+
+	APIGroups           []string
+	AllNamespaces       bool
+	ChunkSize           int64
+	ClusterScoped       string
+	CmdParent           string
+	Contexts            []string
+	CrossNamespaceEdges bool
+	Depth               int
+	EdgeAnnotations     []string
+	ExcludeKinds        []string
+	ExplicitNamespace   bool
+	FieldSelector       string
+	Fields              []string
+	IncludeEvents       bool
+	IncludeKinds        []string
+	KindsOnly           bool
+	LabelSelector       string
+	Layout              string
+	Legend              bool
+	MaxNodes            int
+	MaxRetries          int
+	Namespace           string
+	NamespaceSelector   string
+	Namespaces          []string
+	NodePods            bool
+	OutputFile          string
+	OutputFormat        string
+	PruneOrphans        bool
+	Quiet               bool
+	RankDir             string
+	Reverse             bool
+	Root                string
+	ShowAge             bool
+	ShowMetrics         bool
+	Since               time.Duration
+	SplitByNamespace    bool
+	StatusColors        map[string]string
+	Timeout             time.Duration
+	Timings             bool
+	Truncate            int
+	Watch               bool
+	WatchInterval       time.Duration
 
 	resource.FilenameOptions
 	genericclioptions.IOStreams
@@ -60,11 +123,18 @@ type GraphOptions struct {
 // NewGraphOptions returns a GraphOptions with default chunk size 500.
 func NewGraphOptions(parent string, flags *genericclioptions.ConfigFlags, streams genericclioptions.IOStreams) *GraphOptions {
 	return &GraphOptions{
-		configFlags: flags,
-		CmdParent:   parent,
-		IOStreams:   streams,
-		ChunkSize:   500,
-		Truncate:    graph.DefaultNodeNameLimit,
+		configFlags:   flags,
+		watched:       make(map[schema.GroupVersionResource]bool),
+		ClusterScoped: "true",
+		CmdParent:     parent,
+		IOStreams:     streams,
+		ChunkSize:     graph.DefaultChunkSize,
+		Depth:         -1,
+		Layout:        graph.DefaultLayout,
+		MaxRetries:    DefaultMaxRetries,
+		RankDir:       graph.DefaultRankDir,
+		Truncate:      graph.DefaultNodeNameLimit,
+		WatchInterval: DefaultWatchInterval,
 	}
 }
 
@@ -74,11 +144,12 @@ func NewCmdGraph(parent string, flags *genericclioptions.ConfigFlags, streams ge
 	o := NewGraphOptions(parent, flags, streams)
 
 	cmd := &cobra.Command{
-		Use:                   fmt.Sprintf("%s graph [(-o|--output=)aql|arangodb|cql|cypher|dot|graphviz|mermaid] (TYPE[.VERSION][.GROUP] ...) [flags]", parent),
+		Use:                   fmt.Sprintf("%s graph [(-o|--output=)aql|arangodb|cql|csv|cypher|d3|dot|gexf|graphml|graphviz|gremlin|json|mermaid|png|svg|tree] (TYPE[.VERSION][.GROUP] ...) [flags]", parent),
 		DisableFlagsInUseLine: true,
 		Short:                 "Visualize one or many resources and relationships",
 		Long:                  graphLong + "\n\n" + cmdutil.SuggestAPIResources(parent),
 		Example:               fmt.Sprintf(graphExample, parent),
+		Args:                  cobra.ArbitraryArgs,
 		Run: func(cmd *cobra.Command, args []string) {
 			cmdutil.CheckErr(o.Complete(f, cmd, args))
 			cmdutil.CheckErr(o.Validate(cmd, args))
@@ -87,14 +158,49 @@ func NewCmdGraph(parent string, flags *genericclioptions.ConfigFlags, streams ge
 	}
 
 	cmd.Flags().BoolP("help", "h", false, fmt.Sprintf("Help for %s graph", parent))
-	cmd.Flags().BoolVarP(&o.AllNamespaces, "all-namespaces", "A", o.AllNamespaces, "If present, list the requested object(s) across all namespaces. Namespace in current context is ignored even if specified with --namespace.")
-	cmd.Flags().Int64Var(&o.ChunkSize, "chunk-size", o.ChunkSize, "Return large lists in chunks rather than all at once. Pass 0 to disable.")
-	cmd.Flags().IntVarP(&o.Truncate, "truncate", "t", o.Truncate, "Truncate node name to N characters. This affects graphviz and mermaid output format.")
-	cmd.Flags().StringVar(&o.FieldSelector, "field-selector", o.FieldSelector, "Selector (field query) to filter on, supports '=', '==', and '!='.(e.g. --field-selector key1=value1,key2=value2). The server only supports a limited number of field queries per type.")
-	cmd.Flags().StringVarP(&o.LabelSelector, "selector", "l", o.LabelSelector, "Selector (label query) to filter on, supports '=', '==', and '!='.(e.g. -l key1=value1,key2=value2)")
-	cmd.Flags().StringVarP(&o.OutputFormat, "output", "o", o.OutputFormat, "Output format. One of: aql|arangodb|cql|cypher|dot|graphviz|mermaid.")
+	cmd.PersistentFlags().StringSliceVar(&o.APIGroups, "api-group", o.APIGroups, "API group of objects to graph, all others are excluded. Without resource types all listable resources of the groups are graphed. Use core for the legacy group. Can be repeated or comma separated.(e.g. --api-group apps,core)")
+	cmd.PersistentFlags().BoolVarP(&o.AllNamespaces, "all-namespaces", "A", o.AllNamespaces, "If present, list the requested object(s) across all namespaces. Namespace in current context is ignored even if specified with --namespace.")
+	cmd.PersistentFlags().Int64Var(&o.ChunkSize, "chunk-size", o.ChunkSize, "Return large lists in chunks rather than all at once. Pass 0 to disable.")
+	cmd.PersistentFlags().StringVar(&o.ClusterScoped, "cluster-scoped", o.ClusterScoped, "Whether to graph cluster-scoped resources. One of: true|false|only. With false only namespaced and with only just cluster-scoped resources are listed.")
+	cmd.PersistentFlags().Lookup("cluster-scoped").NoOptDefVal = "true"
+	cmd.PersistentFlags().StringSliceVar(&o.Contexts, "contexts", o.Contexts, "The names of the kubeconfig contexts to graph together. Can be repeated or comma separated. Takes precedence over --context.(e.g. --contexts hub,spoke-1)")
+	cmd.PersistentFlags().IntVar(&o.Depth, "depth", o.Depth, "Limit the graph to nodes within N relationships of the requested object(s). Pass -1 to disable.")
+	cmd.PersistentFlags().IntVarP(&o.Truncate, "truncate", "t", o.Truncate, "Truncate node name to N characters. This affects graphviz and mermaid output format.")
+	cmd.PersistentFlags().StringSliceVar(&o.ExcludeKinds, "exclude-kind", o.ExcludeKinds, "Kind of objects to exclude from the graph. Can be repeated or comma separated.(e.g. --exclude-kind Event,EndpointSlice)")
+	cmd.PersistentFlags().BoolVar(&o.IncludeEvents, "include-events", o.IncludeEvents, "If present, include the events of the namespace and relate them to their involved object. Events are excluded by default, unless requested as resource type or by filename.")
+	cmd.PersistentFlags().BoolVar(&o.KindsOnly, "kinds-only", o.KindsOnly, "If present, collapse all objects of a kind into a single node and weight the relationships by the number of objects they represent.")
+	cmd.PersistentFlags().StringSliceVar(&o.IncludeKinds, "include-kind", o.IncludeKinds, "Kind of objects to include in the graph, all others are excluded. Takes precedence over --exclude-kind.(e.g. --include-kind Deployment,Pod)")
+	cmd.PersistentFlags().StringVar(&o.FieldSelector, "field-selector", o.FieldSelector, "Selector (field query) to filter on, supports '=', '==', and '!='.(e.g. --field-selector key1=value1,key2=value2). The server only supports a limited number of field queries per type.")
+	cmd.PersistentFlags().StringSliceVar(&o.EdgeAnnotations, "edge-from-annotation", o.EdgeAnnotations, "Annotation whose value is a comma separated list of NAME or KIND/NAME, which are related to the annotated object. A name without kind references an object of the same kind. Can be repeated or comma separated.(e.g. --edge-from-annotation relatedTo)")
+	cmd.PersistentFlags().StringSliceVar(&o.Fields, "fields", o.Fields, "JSONPath expressions of fields to add to each node, missing fields are omitted. This affects json output format.(e.g. --fields status.phase,spec.replicas)")
+	cmd.PersistentFlags().StringVarP(&o.LabelSelector, "selector", "l", o.LabelSelector, "Selector (label query) to filter on, supports '=', '==', and '!='.(e.g. -l key1=value1,key2=value2)")
+	cmd.PersistentFlags().IntVar(&o.MaxNodes, "max-nodes", o.MaxNodes, "Limit the graph to N nodes, keeping the requested object(s) and the nodes closest to them. Pass 0 to disable.")
+	cmd.PersistentFlags().IntVar(&o.MaxRetries, "max-retries", o.MaxRetries, "Retry a request up to N times with an exponential backoff, if the API server is overloaded or unavailable. Pass 0 to disable.")
+	cmd.PersistentFlags().DurationVar(&o.Timeout, "timeout", o.Timeout, "The length of time to wait before giving up on building the graph, like 30s or 5m. Pass 0 to disable.")
+	cmd.PersistentFlags().BoolVar(&o.Timings, "timings", o.Timings, "If present, print the duration of the requests to the API server per resource and the total build time to stderr.")
+	cmd.PersistentFlags().DurationVar(&o.Since, "since", o.Since, "Only graph objects created or changed within the duration, like 5m or 2h. Referenced objects which are older are still drawn. Pass 0 to disable.")
+	cmd.PersistentFlags().BoolVar(&o.PruneOrphans, "prune-orphans", o.PruneOrphans, "If present, remove all nodes without any relationship, except the requested object(s).")
+	cmd.PersistentFlags().BoolVarP(&o.Quiet, "quiet", "q", o.Quiet, "If present, do not print progress and the summary of the graph to stderr. Errors are still printed.")
+	cmd.PersistentFlags().BoolVar(&o.ShowAge, "show-age", o.ShowAge, "If present, append the age of the resource to the node name. This affects graphviz and mermaid output format.")
+	cmd.PersistentFlags().BoolVar(&o.ShowMetrics, "show-metrics", o.ShowMetrics, "If present, append the current cpu and memory usage of metrics-server to the pod nodes. This affects graphviz output format.")
+	cmd.PersistentFlags().StringVar(&o.Layout, "layout", o.Layout, "Layout engine of the graphviz output format. One of: circo|dot|fdp|neato|sfdp|twopi.")
+	cmd.PersistentFlags().BoolVar(&o.Legend, "legend", o.Legend, "If present, add a legend of the status colors and relationship labels. This affects graphviz output format.")
+	cmd.PersistentFlags().StringVar(&o.RankDir, "rankdir", o.RankDir, "Direction of the graphviz output format. One of: TB|LR|BT|RL.")
+	cmd.PersistentFlags().BoolVar(&o.Reverse, "reverse", o.Reverse, "If true, graph the workloads and pods which reference the requested object(s) instead of the objects they reference.")
+	cmd.PersistentFlags().StringVar(&o.Root, "root", o.Root, "Graph only the object TYPE[.VERSION][.GROUP]/NAME and its relationships instead of listing resources.(e.g. --root deployment/web)")
+	cmd.PersistentFlags().BoolVarP(&o.Watch, "watch", "w", o.Watch, "If present, watch the requested object(s) and write the graph again to the output file after each change. Requires --output-file.")
+	cmd.PersistentFlags().DurationVar(&o.WatchInterval, "watch-interval", o.WatchInterval, "The length of time to collect changes before the graph is written again in --watch mode, like 2s or 1m.")
+	cmd.PersistentFlags().StringVar(&o.NamespaceSelector, "namespace-selector", o.NamespaceSelector, "Selector (label query) to filter namespaces on. Only objects within matching namespaces and cluster-scoped objects are graphed.(e.g. --namespace-selector team=payments)")
+	cmd.PersistentFlags().BoolVar(&o.NodePods, "node-pods", o.NodePods, "If present, relate each graphed node, like the nodes of a karpenter NodePool, to the running pods scheduled on it.")
+	cmd.PersistentFlags().StringVar(&o.OutputFile, "output-file", o.OutputFile, "If non-empty, write the graph to this file instead of stdout.")
+	cmd.PersistentFlags().BoolVar(&o.SplitByNamespace, "split-by-namespace", o.SplitByNamespace, "If present, write the nodes of each namespace to a separate <basename>-<namespace>.<ext> file. Requires --output-file.")
+	cmd.PersistentFlags().BoolVar(&o.CrossNamespaceEdges, "cross-namespace-edges", o.CrossNamespaceEdges, "If present, keep the relationships to nodes of other namespaces in each file of --split-by-namespace. They are dropped by default.")
+	cmd.PersistentFlags().StringToStringVar(&o.StatusColors, "status-color", o.StatusColors, "Override the graphviz fill color of nodes in a state. (e.g. --status-color Running=#00ff00,Pending=#ffff00)")
+	cmd.PersistentFlags().StringVarP(&o.OutputFormat, "output", "o", o.OutputFormat, "Output format. One of: aql|arangodb|cql|csv|cypher|d3|dot|gexf|graphml|graphviz|gremlin|json|mermaid|png|svg|tree.")
 	cmdutil.AddFilenameOptionFlags(cmd, &o.FilenameOptions, "identifying the resource to get from a server.")
-	o.configFlags.AddFlags(cmd.Flags())
+	o.configFlags.AddFlags(cmd.PersistentFlags())
+
+	cmd.AddCommand(NewCmdConsumers(parent, f, o))
 
 	return cmd
 }
@@ -109,10 +215,27 @@ func (o *GraphOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []st
 	}
 	o.Namespaces = strings.Split(o.Namespace, ",")
 
+	if len(o.NamespaceSelector) != 0 {
+		o.AllNamespaces = true
+	}
+
 	if o.AllNamespaces {
 		o.ExplicitNamespace = false
 	}
 
+	if o.Timings {
+		o.timings = NewTimings()
+	}
+	o.configFlags.WrapConfigFn = o.WrapConfig
+
+	// Events requested explicitly as resource type or in a manifest are graphed without --include-events.
+	switch {
+	case !o.IncludeEvents && !RequestsEvents(args) && cmdutil.IsFilenameSliceEmpty(o.Filenames, o.Kustomize):
+		o.ExcludeKinds = append(o.ExcludeKinds, "Event")
+	case len(o.IncludeKinds) != 0:
+		o.IncludeKinds = append(o.IncludeKinds, "Event")
+	}
+
 	switch o.OutputFormat {
 	case "aql":
 		o.OutputFormat = "arangodb"
@@ -125,34 +248,264 @@ func (o *GraphOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []st
 	return nil
 }
 
+// RequestsEvents returns true if the resource arguments request events, like "events", "ev/NAME" or "pods,events.events.k8s.io".
+func RequestsEvents(args []string) bool {
+	for _, arg := range args {
+		resources, _, _ := strings.Cut(arg, "/")
+		for _, resource := range strings.Split(resources, ",") {
+			name, _, _ := strings.Cut(strings.ToLower(resource), ".")
+			switch name {
+			case "ev", "event", "events":
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 // Validate checks the set of flags provided by the user.
 func (o *GraphOptions) Validate(cmd *cobra.Command, args []string) error {
-	if len(args) == 0 && cmdutil.IsFilenameSliceEmpty(o.Filenames, o.Kustomize) {
+	noResources := len(args) == 0 && cmdutil.IsFilenameSliceEmpty(o.Filenames, o.Kustomize)
+	switch {
+	case len(o.Root) == 0 && noResources && len(o.APIGroups) == 0:
 		return fmt.Errorf("you must specify the type of resource to graph. %s", cmdutil.SuggestAPIResources(o.CmdParent))
+	case len(o.Root) != 0 && !noResources:
+		return fmt.Errorf("--root cannot be used together with resources or filenames")
+	case len(o.Root) != 0 && !strings.Contains(o.Root, "/"):
+		return fmt.Errorf("invalid root: %q, must be in the form TYPE[.VERSION][.GROUP]/NAME", o.Root)
+	case o.SplitByNamespace && len(o.OutputFile) == 0:
+		return fmt.Errorf("--split-by-namespace requires --output-file")
+	case o.Watch && len(o.OutputFile) == 0:
+		return fmt.Errorf("--watch requires --output-file")
+	case o.Watch && len(o.Contexts) != 0:
+		return fmt.Errorf("--watch cannot be used together with --contexts")
+	case o.MaxNodes < 0:
+		return fmt.Errorf("invalid max nodes: %d, must not be negative", o.MaxNodes)
+	case o.Watch && o.WatchInterval <= 0:
+		return fmt.Errorf("invalid watch interval: %v, must be greater than 0", o.WatchInterval)
 	}
-	if !(o.OutputFormat == "arangodb" || o.OutputFormat == "cypher" || o.OutputFormat == "graphviz" || o.OutputFormat == "mermaid") {
-		return fmt.Errorf("invalid output format: %q, allowed formats are: %s", o.OutputFormat, "aql|arangodb|cql|cypher|dot|graphviz|mermaid")
+	switch o.OutputFormat {
+	case "arangodb", "csv", "cypher", "d3", "gexf", "graphml", "graphviz", "gremlin", "json", "mermaid", "tree":
+	case "png", "svg":
+		if _, err := LookPathDot(o.OutputFormat); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid output format: %q, allowed formats are: %s", o.OutputFormat, "aql|arangodb|cql|csv|cypher|d3|dot|gexf|graphml|graphviz|gremlin|json|mermaid|png|svg|tree")
+	}
+	switch o.ClusterScoped {
+	case "true", "false", "only":
+	default:
+		return fmt.Errorf("invalid cluster scoped: %q, allowed values are: %s", o.ClusterScoped, "true|false|only")
+	}
+	switch o.Layout {
+	case "circo", "dot", "fdp", "neato", "sfdp", "twopi":
+	default:
+		return fmt.Errorf("invalid layout: %q, allowed layouts are: %s", o.Layout, "circo|dot|fdp|neato|sfdp|twopi")
+	}
+	switch o.RankDir {
+	case "TB", "LR", "BT", "RL":
+	default:
+		return fmt.Errorf("invalid rankdir: %q, allowed directions are: %s", o.RankDir, "TB|LR|BT|RL")
 	}
 
 	return nil
 }
 
+// WrapConfig wraps the transport of the config to retry transient failures and to record the timings, if enabled.
+// The timings are recorded around the retries, so they include the time spent waiting for the backoff.
+func (o *GraphOptions) WrapConfig(config *rest.Config) *rest.Config {
+	if o.MaxRetries > 0 {
+		config = NewRetry(o.MaxRetries).WrapConfig(config)
+	}
+	if o.timings != nil {
+		config = o.timings.WrapConfig(config)
+	}
+
+	return config
+}
+
 // Run performs the graph operation.
 func (o *GraphOptions) Run(f cmdutil.Factory, cmd *cobra.Command, args []string) error {
+	info := o.ErrOut
+	if o.Quiet {
+		info = io.Discard
+	}
+
+	// The root object is requested like TYPE/NAME, so only this object is retrieved and expanded.
+	if len(o.Root) != 0 {
+		args = []string{o.Root}
+	}
+
+	if o.Watch {
+		return o.WatchGraph(f, args, info)
+	}
+
+	return o.Generate(context.Background(), f, args, info)
+}
+
+// Generate builds the graph of all requested objects and writes it to the output.
+func (o *GraphOptions) Generate(ctx context.Context, f cmdutil.Factory, args []string, info io.Writer) error {
+	start := time.Now()
+
+	if o.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.Timeout)
+		defer cancel()
+	}
+
+	var g *graph.Graph
+	if len(o.Contexts) == 0 {
+		var err error
+		if g, err = o.BuildGraph(ctx, f, args, info); err != nil {
+			return err
+		}
+	}
+
+	// The graphs of multiple contexts are merged into one, where the nodes are prefixed by the context.
+	for _, name := range o.Contexts {
+		c, err := o.BuildGraph(ctx, cmdutil.NewFactory(o.ContextConfigFlags(name)), args, info)
+		if err != nil {
+			return fmt.Errorf("context %q: %v", name, err)
+		}
+		if g == nil {
+			g = graph.NewGraph(nil)
+			g.Options = c.Options
+		}
+		g.Merge(c, name)
+	}
+
+	if o.PruneOrphans {
+		g.PruneOrphans()
+	}
+
+	if o.MaxNodes > 0 {
+		if removed := g.Truncate(o.MaxNodes); removed > 0 {
+			fmt.Fprintf(o.ErrOut, "warning: the graph is truncated to %d nodes, %d nodes are removed\n", o.MaxNodes, removed)
+		}
+	}
+
+	if o.KindsOnly {
+		g.Aggregate()
+	}
+
+	for _, cycle := range g.Cycles() {
+		names := []string{}
+		for _, n := range cycle {
+			names = append(names, fmt.Sprintf("%s/%s", n.Kind, n.Name))
+		}
+		fmt.Fprintf(info, "warning: cycle detected: %s\n", strings.Join(names, " -> "))
+	}
+
+	// The tree output requires a hierarchy, so a relationship of each cycle is dropped.
+	if o.OutputFormat == "tree" {
+		g.BreakCycles()
+	}
+
+	namespaces := 0
+	for namespace := range g.NodeListByNamespace() {
+		if len(namespace) != 0 {
+			namespaces++
+		}
+	}
+	fmt.Fprintf(info, "graph: %d nodes, %d edges across %d namespaces\n", len(g.Nodes), len(g.RelationshipList()), namespaces)
+
+	if o.timings != nil {
+		o.timings.Print(o.ErrOut, time.Since(start))
+	}
+
+	if len(o.OutputFile) == 0 {
+		return o.Write(o.Out, g)
+	}
+
+	if !o.SplitByNamespace {
+		return o.WriteOutputFile(g, o.OutputFile)
+	}
+
+	// Each namespace is written to <basename>-<namespace>.<ext> and all cluster-scoped nodes to <basename>-cluster-scoped.<ext>.
+	ext := filepath.Ext(o.OutputFile)
+	graphs := g.Split(o.CrossNamespaceEdges)
+	names := []string{}
+	for namespace := range graphs {
+		names = append(names, namespace)
+	}
+	sort.Strings(names)
+
+	for _, namespace := range names {
+		sub := graphs[namespace]
+		if len(namespace) == 0 {
+			namespace = "cluster-scoped"
+		}
+		if err := o.WriteOutputFile(sub, strings.TrimSuffix(o.OutputFile, ext)+"-"+namespace+ext); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ContextConfigFlags returns a copy of the config flags, which only overrides the kubeconfig context.
+// The copy does not share the cached client config, so each context gets its own clients.
+func (o *GraphOptions) ContextConfigFlags(context string) *genericclioptions.ConfigFlags {
+	flags := genericclioptions.NewConfigFlags(true)
+
+	flags.CacheDir = o.configFlags.CacheDir
+	flags.KubeConfig = o.configFlags.KubeConfig
+	flags.ClusterName = o.configFlags.ClusterName
+	flags.AuthInfoName = o.configFlags.AuthInfoName
+	flags.Context = &context
+	flags.Namespace = o.configFlags.Namespace
+	flags.APIServer = o.configFlags.APIServer
+	flags.TLSServerName = o.configFlags.TLSServerName
+	flags.Insecure = o.configFlags.Insecure
+	flags.CertFile = o.configFlags.CertFile
+	flags.KeyFile = o.configFlags.KeyFile
+	flags.CAFile = o.configFlags.CAFile
+	flags.BearerToken = o.configFlags.BearerToken
+	flags.Impersonate = o.configFlags.Impersonate
+	flags.ImpersonateUID = o.configFlags.ImpersonateUID
+	flags.ImpersonateGroup = o.configFlags.ImpersonateGroup
+	flags.Username = o.configFlags.Username
+	flags.Password = o.configFlags.Password
+	flags.Timeout = o.configFlags.Timeout
+	flags.DisableCompression = o.configFlags.DisableCompression
+	flags.WrapConfigFn = o.configFlags.WrapConfigFn
+
+	return flags
+}
+
+// BuildGraph retrieves all requested objects with the factory and returns the graph of them.
+func (o *GraphOptions) BuildGraph(ctx context.Context, f cmdutil.Factory, args []string, info io.Writer) (*graph.Graph, error) {
 	config, err := f.ToRESTConfig()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	fmt.Fprintf(o.ErrOut, "Please wait while retrieving data from %s\n", config.Host)
+	fmt.Fprintf(info, "Please wait while retrieving data from %s\n", config.Host)
 
 	clientset, err := f.KubernetesClientSet()
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	// Only the resources of the API groups are listed, if no resource types are requested.
+	if len(o.APIGroups) != 0 && len(args) == 0 && cmdutil.IsFilenameSliceEmpty(o.Filenames, o.Kustomize) {
+		if args, err = o.APIGroupResources(f); err != nil {
+			return nil, err
+		}
+	}
+
+	if args, err = o.FilterResourcesByScope(f, args); err != nil {
+		return nil, err
 	}
 
-	objs := []*unstructured.Unstructured{}
+	objs, errs := []*unstructured.Unstructured{}, []error{}
 	for _, namespace := range o.Namespaces {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
 		r := f.NewBuilder().
 			Unstructured().
 			NamespaceParam(namespace).DefaultNamespace().AllNamespaces(o.AllNamespaces).
@@ -162,27 +515,82 @@ func (o *GraphOptions) Run(f cmdutil.Factory, cmd *cobra.Command, args []string)
 			RequestChunksOf(o.ChunkSize).
 			ResourceTypeOrNameArgs(true, args...).
 			ContinueOnError().
-			Latest().
 			Flatten().
 			Do()
 
 		if err := r.Err(); err != nil {
-			return err
+			return nil, err
 		}
 
+		// Errors of a single resource, e.g. forbidden by RBAC, should not prevent graphing the others.
 		infos, err := r.Infos()
 		if err != nil {
-			return err
+			errs = append(errs, err)
 		}
 
 		for _, info := range infos {
+			if !o.MatchesScope(info.Mapping.Scope.Name() == meta.RESTScopeNameNamespace) {
+				continue
+			}
+			if !cmdutil.IsFilenameSliceEmpty(o.Filenames, o.Kustomize) {
+				if err := o.Latest(info); err != nil {
+					errs = append(errs, err)
+					continue
+				}
+			}
 			objs = append(objs, info.Object.(*unstructured.Unstructured))
+			o.watched[info.Mapping.Resource] = info.Mapping.Scope.Name() == meta.RESTScopeNameNamespace
+		}
+
+		if !o.IncludeEvents {
+			continue
+		}
+
+		r = f.NewBuilder().
+			Unstructured().
+			NamespaceParam(namespace).DefaultNamespace().AllNamespaces(o.AllNamespaces).
+			RequestChunksOf(o.ChunkSize).
+			ResourceTypeOrNameArgs(true, "events").
+			ContinueOnError().
+			Latest().
+			Flatten().
+			Do()
+
+		infos, err = r.Infos()
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		for _, info := range infos {
+			objs = append(objs, info.Object.(*unstructured.Unstructured))
+			o.watched[info.Mapping.Resource] = info.Mapping.Scope.Name() == meta.RESTScopeNameNamespace
 		}
 	}
 
-	bar := progressbar.NewOptions(len(objs),
+	o.PrintErrors(errs, "some resources could not be retrieved")
+
+	if len(o.NamespaceSelector) != 0 {
+		objs, err = o.FilterByNamespaceSelector(ctx, clientset, objs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	objs = o.FilterByAPIGroup(objs)
+	objs = o.FilterByKind(objs)
+	objs = o.FilterBySince(objs, time.Now())
+
+	referrers := []*unstructured.Unstructured{}
+	if o.Reverse {
+		referrers, err = o.ListReferrers(f, objs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	bar := progressbar.NewOptions(len(objs)+len(referrers),
 		progressbar.OptionSetDescription("Processing..."),
-		progressbar.OptionSetWriter(o.ErrOut),
+		progressbar.OptionSetWriter(info),
 		progressbar.OptionSetWidth(10+len(config.Host)),
 		progressbar.OptionShowCount(),
 		progressbar.OptionSetTheme(progressbar.Theme{
@@ -194,18 +602,378 @@ func (o *GraphOptions) Run(f cmdutil.Factory, cmd *cobra.Command, args []string)
 		}),
 		progressbar.OptionSetPredictTime(false),
 		progressbar.OptionOnCompletion(func() {
-			fmt.Fprint(o.ErrOut, "\n")
+			fmt.Fprint(info, "\n")
 		}),
 	)
 
-	graph, err := graph.NewGraph(clientset, objs, func() { bar.Add(1) })
+	g := graph.NewGraph(clientset)
+	g.Options.ChunkSize = o.ChunkSize
+	g.Options.EdgeAnnotations = o.EdgeAnnotations
+	g.Options.Fields = o.Fields
+	g.Options.Layout = o.Layout
+	g.Options.Legend = o.Legend
+	g.Options.NodePods = o.NodePods
+	g.Options.RankDir = o.RankDir
+	g.Options.ShowAge = o.ShowAge
+	g.Options.Processed = func() { bar.Add(1) }
+
+	if o.Truncate > 0 {
+		g.Options.NodeNameLimit = o.Truncate
+	}
+
+	for state, color := range o.StatusColors {
+		g.Options.StatusColors[state] = color
+	}
+
+	// The objects which failed are reported, while the graph of all other objects is still written.
+	errs = []error{}
+	if _, err := g.Build(ctx, objs); err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		errs = append(errs, err)
+	}
+
+	if o.Reverse {
+		if _, err := g.Referrers(ctx, referrers); err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			errs = append(errs, err)
+		}
+	}
+
+	o.PrintErrors(errs, "some objects could not be graphed")
+
+	if o.Depth >= 0 {
+		g.Limit(o.Depth)
+	}
+
+	// The graph is written without usage, if metrics-server is not installed or the metrics are not allowed to be listed.
+	if o.ShowMetrics {
+		if err := g.PodMetrics(ctx); err != nil {
+			fmt.Fprintf(o.ErrOut, "warning: the pod metrics are not shown: %v\n", err)
+		}
+	}
+
+	return g, nil
+}
+
+// PrintErrors writes all errors and a warning with the reason, why the graph may be incomplete, to the error output.
+func (o *GraphOptions) PrintErrors(errs []error, reason string) {
+	err := utilerrors.NewAggregate(errs)
+	if err == nil {
+		return
+	}
+
+	for _, err := range utilerrors.Flatten(err).Errors() {
+		fmt.Fprintf(o.ErrOut, "error: %v\n", err)
+	}
+	fmt.Fprintf(o.ErrOut, "warning: the graph may be incomplete, because %s\n", reason)
+}
+
+// ListReferrers retrieves all workloads and pods within the namespaces of the objects, which may reference them.
+func (o *GraphOptions) ListReferrers(f cmdutil.Factory, objs []*unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	namespaces := make(map[string]bool)
+	for _, obj := range objs {
+		namespaces[obj.GetNamespace()] = true
+	}
+
+	referrers := []*unstructured.Unstructured{}
+	for namespace := range namespaces {
+		if len(namespace) == 0 {
+			continue
+		}
+
+		r := f.NewBuilder().
+			Unstructured().
+			NamespaceParam(namespace).
+			RequestChunksOf(o.ChunkSize).
+			ResourceTypeOrNameArgs(true, "pods,replicasets,deployments,statefulsets,daemonsets,jobs,cronjobs").
+			ContinueOnError().
+			Latest().
+			Flatten().
+			Do()
+
+		infos, err := r.Infos()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list referrers in namespace %q: %v", namespace, err)
+		}
+
+		for _, info := range infos {
+			referrers = append(referrers, info.Object.(*unstructured.Unstructured))
+		}
+	}
+
+	return referrers, nil
+}
+
+// Latest replaces the object of a manifest by the latest copy from the server.
+// An object which does not exist yet is kept as in the manifest, with the UID of a reference to the object,
+// so it is graphed like it was applied and the relationships to the object are resolved to it.
+func (o *GraphOptions) Latest(info *resource.Info) error {
+	err := info.Get()
+	if !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	obj := info.Object.(*unstructured.Unstructured)
+	if len(obj.GetNamespace()) == 0 && info.Namespaced() {
+		obj.SetNamespace(info.Namespace)
+	}
+	gvk := obj.GroupVersionKind()
+	obj.SetUID(graph.ToUID(gvk.Group, gvk.Kind, obj.GetNamespace(), obj.GetName()))
+
+	return nil
+}
+
+// FilterByNamespaceSelector returns all cluster-scoped objects and all objects within namespaces matching the namespace selector.
+// The namespaces are selected by the API server and namespace objects which are not selected are removed as well.
+func (o *GraphOptions) FilterByNamespaceSelector(ctx context.Context, clientset *kubernetes.Clientset, objs []*unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	options := metav1.ListOptions{LabelSelector: o.NamespaceSelector}
+	namespaces, err := clientset.CoreV1().Namespaces().List(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]bool, len(namespaces.Items))
+	for _, namespace := range namespaces.Items {
+		selected[namespace.GetName()] = true
+	}
+
+	filtered := []*unstructured.Unstructured{}
+	for _, obj := range objs {
+		switch {
+		case len(obj.GroupVersionKind().Group) == 0 && obj.GetKind() == "Namespace":
+			if selected[obj.GetName()] {
+				filtered = append(filtered, obj)
+			}
+		case len(obj.GetNamespace()) == 0 || selected[obj.GetNamespace()]:
+			filtered = append(filtered, obj)
+		}
+	}
+
+	return filtered, nil
+}
+
+// APIGroupResources returns the listable resources of the preferred versions of the API groups as a single argument.
+func (o *GraphOptions) APIGroupResources(f cmdutil.Factory) ([]string, error) {
+	client, err := f.ToDiscoveryClient()
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := client.ServerGroups()
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover api groups: %v", err)
+	}
+
+	resources := []string{}
+	for _, group := range groups.Groups {
+		if !o.MatchesAPIGroup(group.Name) {
+			continue
+		}
+
+		list, err := client.ServerResourcesForGroupVersion(group.PreferredVersion.GroupVersion)
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover resources of %q: %v", group.PreferredVersion.GroupVersion, err)
+		}
+
+		for _, resource := range list.APIResources {
+			if strings.Contains(resource.Name, "/") || !slices.Contains(resource.Verbs, "list") || !o.MatchesScope(resource.Namespaced) {
+				continue
+			}
+			if len(group.Name) == 0 {
+				resources = append(resources, resource.Name)
+				continue
+			}
+			resources = append(resources, strings.Join([]string{resource.Name, group.PreferredVersion.Version, group.Name}, "."))
+		}
+	}
+
+	if len(resources) == 0 {
+		return nil, fmt.Errorf("no listable resources found in api groups: %s", strings.Join(o.APIGroups, ","))
+	}
+
+	return []string{strings.Join(resources, ",")}, nil
+}
+
+// MatchesAPIGroup returns true if the group is one of the API groups, where core matches the legacy group.
+func (o *GraphOptions) MatchesAPIGroup(group string) bool {
+	for _, g := range o.APIGroups {
+		if strings.EqualFold(g, group) || (g == "core" && len(group) == 0) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MatchesScope returns true if resources of the scope are graphed with the cluster-scoped option.
+func (o *GraphOptions) MatchesScope(namespaced bool) bool {
+	switch o.ClusterScoped {
+	case "false":
+		return namespaced
+	case "only":
+		return !namespaced
+	}
+
+	return true
+}
+
+// FilterResourcesByScope removes the resource types of the arguments, which are skipped with the cluster-scoped option,
+// so they are not listed at all. Arguments with names and unknown types like categories are kept as they are,
+// since their objects are filtered after they are retrieved.
+func (o *GraphOptions) FilterResourcesByScope(f cmdutil.Factory, args []string) ([]string, error) {
+	if o.ClusterScoped == "true" || len(args) != 1 || strings.Contains(args[0], "/") {
+		return args, nil
+	}
+
+	mapper, err := f.ToRESTMapper()
+	if err != nil {
+		return nil, err
+	}
+
+	resources := []string{}
+	for _, resource := range strings.Split(args[0], ",") {
+		gvr, gr := schema.ParseResourceArg(resource)
+		if gvr == nil {
+			gvr = &schema.GroupVersionResource{}
+		}
+
+		gvk, err := mapper.KindFor(*gvr)
+		if gvk.Empty() {
+			gvk, err = mapper.KindFor(gr.WithVersion(""))
+		}
+		if err != nil {
+			resources = append(resources, resource)
+			continue
+		}
+
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil || o.MatchesScope(mapping.Scope.Name() == meta.RESTScopeNameNamespace) {
+			resources = append(resources, resource)
+		}
+	}
+
+	if len(resources) == 0 {
+		return nil, fmt.Errorf("no resource types remain to graph with --cluster-scoped=%s", o.ClusterScoped)
+	}
+
+	return []string{strings.Join(resources, ",")}, nil
+}
+
+// FilterByAPIGroup returns all objects of the API groups or all objects if no API groups are set.
+func (o *GraphOptions) FilterByAPIGroup(objs []*unstructured.Unstructured) []*unstructured.Unstructured {
+	if len(o.APIGroups) == 0 {
+		return objs
+	}
+
+	filtered := []*unstructured.Unstructured{}
+	for _, obj := range objs {
+		if o.MatchesAPIGroup(obj.GroupVersionKind().Group) {
+			filtered = append(filtered, obj)
+		}
+	}
+
+	return filtered
+}
+
+// FilterByKind returns all objects of included kinds or, if no kinds are included, all objects which are not excluded.
+func (o *GraphOptions) FilterByKind(objs []*unstructured.Unstructured) []*unstructured.Unstructured {
+	kinds, include := o.ExcludeKinds, false
+	if len(o.IncludeKinds) != 0 {
+		kinds, include = o.IncludeKinds, true
+	}
+
+	if len(kinds) == 0 {
+		return objs
+	}
+
+	filtered := []*unstructured.Unstructured{}
+	for _, obj := range objs {
+		matched := false
+		for _, kind := range kinds {
+			if strings.EqualFold(obj.GetKind(), kind) {
+				matched = true
+				break
+			}
+		}
+		if matched == include {
+			filtered = append(filtered, obj)
+		}
+	}
+
+	return filtered
+}
+
+// FilterBySince returns all objects created or changed within the since duration before now.
+// The time of the last change is read from the managed fields and the transition time of the conditions.
+func (o *GraphOptions) FilterBySince(objs []*unstructured.Unstructured, now time.Time) []*unstructured.Unstructured {
+	if o.Since <= 0 {
+		return objs
+	}
+
+	cutoff := now.Add(-o.Since)
+
+	filtered := []*unstructured.Unstructured{}
+	for _, obj := range objs {
+		changed := obj.GetCreationTimestamp().Time
+		for _, field := range obj.GetManagedFields() {
+			if field.Time != nil && field.Time.After(changed) {
+				changed = field.Time.Time
+			}
+		}
+
+		conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+		for _, condition := range conditions {
+			c, ok := condition.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			timestamp, _, _ := unstructured.NestedString(c, "lastTransitionTime")
+			if t, err := time.Parse(time.RFC3339, timestamp); err == nil && t.After(changed) {
+				changed = t
+			}
+		}
+
+		if !changed.Before(cutoff) {
+			filtered = append(filtered, obj)
+		}
+	}
+
+	return filtered
+}
+
+// WriteOutputFile writes the graph in the output format to the named file.
+func (o *GraphOptions) WriteOutputFile(g *graph.Graph, name string) error {
+	file, err := o.CreateOutputFile(name)
 	if err != nil {
 		return err
 	}
 
-	if o.Truncate > 0 {
-		graph.Options.NodeNameLimit = o.Truncate
+	if err := o.Write(file, g); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// CreateOutputFile creates or truncates the named output file including all missing parent directories.
+func (o *GraphOptions) CreateOutputFile(name string) (*os.File, error) {
+	if info, err := os.Stat(name); err == nil && info.IsDir() {
+		return nil, fmt.Errorf("output file %q is a directory", name)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory for output file %q: %v", name, err)
+	}
+
+	file, err := os.Create(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file %q: %v", name, err)
 	}
 
-	return graph.Write(o.Out, o.OutputFormat)
+	return file, nil
 }
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// newTestObject returns an unstructured object of the kind within the namespace.
+func newTestObject(apiVersion string, kind string, namespace string, name string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+
+	return obj
+}
+
+func TestFilterByNamespaceSelector(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/namespaces" {
+			http.NotFound(w, r)
+			return
+		}
+		if selector := r.URL.Query().Get("labelSelector"); selector != "team=payments" {
+			t.Errorf("expected the namespace selector to be sent to the server, got %q", selector)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"kind": "NamespaceList", "apiVersion": "v1", "items": [{"metadata": {"name": "payments"}}]}`))
+	}))
+	defer server.Close()
+
+	clientset := kubernetes.NewForConfigOrDie(&rest.Config{Host: server.URL})
+	o := &GraphOptions{NamespaceSelector: "team=payments"}
+	objs := []*unstructured.Unstructured{
+		newTestObject("v1", "Namespace", "", "payments"),
+		newTestObject("v1", "Namespace", "", "kube-system"),
+		newTestObject("v1", "Pod", "payments", "api"),
+		newTestObject("v1", "Pod", "kube-system", "coredns"),
+		newTestObject("v1", "Node", "", "worker"),
+	}
+
+	filtered, err := o.FilterByNamespaceSelector(context.Background(), clientset, objs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := []string{}
+	for _, obj := range filtered {
+		got = append(got, obj.GetKind()+"/"+obj.GetName())
+	}
+	want := []string{"Namespace/payments", "Pod/api", "Node/worker"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestPrintErrors(t *testing.T) {
+	errOut := &bytes.Buffer{}
+	o := &GraphOptions{}
+	o.ErrOut = errOut
+
+	o.PrintErrors(nil, "nothing failed")
+	if errOut.Len() != 0 {
+		t.Fatalf("expected no output without errors, got %q", errOut.String())
+	}
+
+	o.PrintErrors([]error{errors.New("forbidden"), errors.New("timeout")}, "some objects could not be graphed")
+	for _, line := range []string{"error: forbidden\n", "error: timeout\n", "warning: the graph may be incomplete, because some objects could not be graphed\n"} {
+		if !strings.Contains(errOut.String(), line) {
+			t.Errorf("expected output to contain %q, got %q", line, errOut.String())
+		}
+	}
+}
+
+func TestContextConfigFlags(t *testing.T) {
+	flags := genericclioptions.NewConfigFlags(true)
+	token, server := "secret", "https://hub.example.com"
+	flags.BearerToken = &token
+	flags.APIServer = &server
+	flags.WrapConfigFn = func(config *rest.Config) *rest.Config { return config }
+
+	o := &GraphOptions{configFlags: flags}
+	c := o.ContextConfigFlags("spoke")
+
+	if *c.Context != "spoke" {
+		t.Errorf("expected context %q, got %q", "spoke", *c.Context)
+	}
+	if c.WrapConfigFn == nil {
+		t.Error("expected the wrap config function to be copied")
+	}
+
+	// Every exported flag except the context is copied, so no flag is dropped for the contexts.
+	original, copied := reflect.ValueOf(flags).Elem(), reflect.ValueOf(c).Elem()
+	for i := 0; i < original.NumField(); i++ {
+		field := original.Type().Field(i)
+		if !field.IsExported() || field.Name == "Context" || field.Type.Kind() != reflect.Ptr {
+			continue
+		}
+		if original.Field(i).Pointer() != copied.Field(i).Pointer() {
+			t.Errorf("expected flag %s to be copied", field.Name)
+		}
+	}
+}
+
+func TestRequestsEvents(t *testing.T) {
+	tests := map[string]bool{
+		"events":                    true,
+		"ev/web.17a":                true,
+		"pods,events.events.k8s.io": true,
+		"Event":                     true,
+		"pods,deployments":          false,
+		"eventsources":              false,
+		"pods/events":               false,
+	}
+
+	for arg, expected := range tests {
+		if actual := RequestsEvents([]string{arg}); actual != expected {
+			t.Errorf("expected %v for %q, got %v", expected, arg, actual)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	consumersLong = templates.LongDesc(`
+		Visualize the pods and workloads which consume a ConfigMap or Secret.
+
+		The relationships are labeled by how the object is consumed, like volume, envFrom, env or imagePullSecret.`)
+
+	consumersExample = templates.Examples(`
+		# Visualize what would break if the configmap app-config is deleted.
+		%[1]s graph consumers configmap/app-config -o tree
+
+		# Visualize the consumers of the secret registry in graphviz output format.
+		%[1]s graph consumers secret/registry -n payments | dot -T svg -o consumers.svg`)
+)
+
+// NewCmdConsumers creates a command object for the "graph consumers" action, which shares the options of the graph command.
+func NewCmdConsumers(parent string, f cmdutil.Factory, o *GraphOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "consumers (configmap|secret)/NAME [flags]",
+		DisableFlagsInUseLine: true,
+		Short:                 "Visualize the consumers of a ConfigMap or Secret",
+		Long:                  consumersLong,
+		Example:               fmt.Sprintf(consumersExample, parent),
+		Args:                  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.CompleteConsumers(args[0]))
+			cmdutil.CheckErr(o.Complete(f, cmd, nil))
+			cmdutil.CheckErr(o.Validate(cmd, nil))
+			cmdutil.CheckErr(o.Run(f, cmd, nil))
+		},
+	}
+
+	return cmd
+}
+
+// CompleteConsumers sets the object as root of a reverse graph, if it is a ConfigMap or Secret.
+func (o *GraphOptions) CompleteConsumers(object string) error {
+	kind, _, _ := strings.Cut(object, "/")
+
+	switch strings.ToLower(kind) {
+	case "cm", "configmap", "configmaps", "secret", "secrets":
+	default:
+		return fmt.Errorf("invalid object: %q, must be in the form configmap/NAME or secret/NAME", object)
+	}
+
+	o.Root = object
+	o.Reverse = true
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+
+	"github.com/steveteuber/kubectl-graph/pkg/graph"
+)
+
+// renderFormats contains the output formats which are rendered from the graphviz output by the dot binary.
+var renderFormats = map[string]bool{
+	"png": true,
+	"svg": true,
+}
+
+// LookPathDot returns the path of the dot binary of Graphviz or an error if it is not found on PATH.
+func LookPathDot(format string) (string, error) {
+	path, err := exec.LookPath("dot")
+	if err != nil {
+		return "", fmt.Errorf("the %s output format requires the dot binary of Graphviz on PATH, install Graphviz or use --output dot", format)
+	}
+
+	return path, nil
+}
+
+// Write writes the graph in the output format to the writer.
+// The png and svg output formats are rendered from the graphviz output by the dot binary.
+func (o *GraphOptions) Write(w io.Writer, g *graph.Graph) error {
+	if !renderFormats[o.OutputFormat] {
+		return g.Write(w, o.OutputFormat)
+	}
+
+	path, err := LookPathDot(o.OutputFormat)
+	if err != nil {
+		return err
+	}
+
+	dot := &bytes.Buffer{}
+	if err := g.Write(dot, "graphviz"); err != nil {
+		return err
+	}
+
+	stderr := &bytes.Buffer{}
+	cmd := exec.Command(path, "-T"+o.OutputFormat)
+	cmd.Stdin = dot
+	cmd.Stdout = w
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to render %s output with dot: %v: %s", o.OutputFormat, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"k8s.io/client-go/rest"
+)
+
+// DefaultMaxRetries represents the default number of retries of a request after a transient failure.
+const DefaultMaxRetries int = 3
+
+// retryBackoff represents the duration to wait before the first retry, which doubles with each retry.
+const retryBackoff = 200 * time.Millisecond
+
+// Retry retries read requests to the API server, which failed with a transient status code.
+type Retry struct {
+	MaxRetries int
+}
+
+// NewRetry creates a new Retry with the maximum number of retries per request.
+func NewRetry(maxRetries int) *Retry {
+	return &Retry{
+		MaxRetries: maxRetries,
+	}
+}
+
+// WrapConfig wraps the transport of the config to retry failed requests with an exponential backoff.
+func (r *Retry) WrapConfig(config *rest.Config) *rest.Config {
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return r.RoundTrip(rt, req)
+		})
+	})
+
+	return config
+}
+
+// RoundTrip sends the request with the transport and retries it as long as it is retryable.
+func (r *Retry) RoundTrip(rt http.RoundTripper, req *http.Request) (*http.Response, error) {
+	backoff := retryBackoff
+
+	for retries := 0; ; retries++ {
+		resp, err := rt.RoundTrip(req)
+		if err != nil || retries >= r.MaxRetries || !Retryable(req, resp) {
+			return resp, err
+		}
+
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// Retryable returns true if the request is a read request and the response has a transient status code
+// like 429 Too Many Requests or 503 Service Unavailable. Errors like Forbidden or NotFound are never retried.
+// A response with a Retry-After header is not retried, because it is already retried by the rest client.
+func Retryable(req *http.Request, resp *http.Response) bool {
+	if req.Method != http.MethodGet {
+		return false
+	}
+
+	if _, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return false
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
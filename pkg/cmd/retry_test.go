@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// newTestTransport creates a transport which responds with the status codes in order, and with 200 OK afterwards.
+// The headers are set on every response, and the number of requests is counted.
+func newTestTransport(count *int, header http.Header, codes ...int) roundTripperFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		code := http.StatusOK
+		if *count < len(codes) {
+			code = codes[*count]
+		}
+		*count++
+
+		return &http.Response{StatusCode: code, Header: header, Body: io.NopCloser(strings.NewReader(""))}, nil
+	}
+}
+
+func TestRetryRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		header   http.Header
+		codes    []int
+		code     int
+		requests int
+	}{
+		{"retries unavailable", http.MethodGet, http.Header{}, []int{http.StatusServiceUnavailable}, http.StatusOK, 2},
+		{"retries too many requests", http.MethodGet, http.Header{}, []int{http.StatusTooManyRequests}, http.StatusOK, 2},
+		{"stops after max retries", http.MethodGet, http.Header{}, []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable}, http.StatusServiceUnavailable, 2},
+		{"leaves retry-after to the rest client", http.MethodGet, http.Header{"Retry-After": []string{"1"}}, []int{http.StatusTooManyRequests}, http.StatusTooManyRequests, 1},
+		{"never retries writes", http.MethodPost, http.Header{}, []int{http.StatusServiceUnavailable}, http.StatusServiceUnavailable, 1},
+		{"never retries not found", http.MethodGet, http.Header{}, []int{http.StatusNotFound}, http.StatusNotFound, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			req, err := http.NewRequest(tt.method, "https://localhost/api/v1/pods", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := NewRetry(1).RoundTrip(newTestTransport(&requests, tt.header, tt.codes...), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.code {
+				t.Errorf("expected status code %d, got %d", tt.code, resp.StatusCode)
+			}
+			if requests != tt.requests {
+				t.Errorf("expected %d requests, got %d", tt.requests, requests)
+			}
+		})
+	}
+}
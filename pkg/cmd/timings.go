@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/rest"
+)
+
+// Timings records the number and the duration of all requests to the API server grouped by resource.
+type Timings struct {
+	mu        sync.Mutex
+	counts    map[string]int
+	durations map[string]time.Duration
+}
+
+// NewTimings creates a new Timings.
+func NewTimings() *Timings {
+	return &Timings{
+		counts:    make(map[string]int),
+		durations: make(map[string]time.Duration),
+	}
+}
+
+// roundTripperFunc implements http.RoundTripper with a function.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip calls the function with the request.
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// WrapConfig wraps the transport of the config to record the duration of each request.
+func (t *Timings) WrapConfig(config *rest.Config) *rest.Config {
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := rt.RoundTrip(req)
+			t.Record(TimingsKey(req), time.Since(start))
+			return resp, err
+		})
+	})
+
+	return config
+}
+
+// Record adds the duration of a single request to the key.
+func (t *Timings) Record(key string, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.counts[key]++
+	t.durations[key] += d
+}
+
+// TimingsKey returns the key of a request like "LIST deployments.v1.apps" or "GET discovery".
+// The namespace and the name of the object are ignored, so all requests of a resource are grouped.
+func TimingsKey(req *http.Request) string {
+	verb := req.Method
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+
+	gvr := []string{}
+	switch {
+	case len(parts) >= 3 && parts[0] == "api":
+		gvr = append(gvr, parts[1])
+		parts = parts[2:]
+	case len(parts) >= 4 && parts[0] == "apis":
+		gvr = append(gvr, parts[2], parts[1])
+		parts = parts[3:]
+	default:
+		return verb + " discovery"
+	}
+
+	if len(parts) >= 3 && parts[0] == "namespaces" {
+		parts = parts[2:]
+	}
+	if verb == http.MethodGet && len(parts) == 1 {
+		verb = "LIST"
+	}
+
+	return verb + " " + strings.Join(append([]string{parts[0]}, gvr...), ".")
+}
+
+// Print writes all recorded keys sorted by the slowest total duration followed by the total build time.
+func (t *Timings) Print(w io.Writer, total time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	keys := make([]string, 0, len(t.durations))
+	for key := range t.durations {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if t.durations[keys[i]] != t.durations[keys[j]] {
+			return t.durations[keys[i]] > t.durations[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	for _, key := range keys {
+		fmt.Fprintf(w, "timing: %-50s %4d requests %10v\n", key, t.counts[key], t.durations[key].Round(time.Millisecond))
+	}
+	fmt.Fprintf(w, "timing: total build time %v\n", total.Round(time.Millisecond))
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+// DefaultWatchInterval represents the default length of time to collect changes before the graph is written again.
+const DefaultWatchInterval time.Duration = 2 * time.Second
+
+// WatchGraph writes the graph to the output file and sets up informers on the resources of the graphed objects.
+// After each change the graph is written again, when no further change happened within the watch interval.
+// It runs until it is interrupted.
+func (o *GraphOptions) WatchGraph(f cmdutil.Factory, args []string, info io.Writer) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := o.Generate(ctx, f, args, info); err != nil {
+		return err
+	}
+
+	client, err := f.DynamicClient()
+	if err != nil {
+		return err
+	}
+
+	namespaces := o.Namespaces
+	if o.AllNamespaces {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+
+	// The informers report all existing objects as added, so changes are only signaled after they are synced.
+	synced := atomic.Bool{}
+	changes := make(chan struct{}, 1)
+	changed := func() {
+		if !synced.Load() {
+			return
+		}
+		select {
+		case changes <- struct{}{}:
+		default:
+		}
+	}
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { changed() },
+		UpdateFunc: func(oldObj, newObj interface{}) { changed() },
+		DeleteFunc: func(obj interface{}) { changed() },
+	}
+	tweak := func(options *metav1.ListOptions) {
+		options.LabelSelector = o.LabelSelector
+		options.FieldSelector = o.FieldSelector
+	}
+
+	factories := []dynamicinformer.DynamicSharedInformerFactory{}
+	for resource, namespaced := range o.watched {
+		scopes := []string{metav1.NamespaceAll}
+		if namespaced {
+			scopes = namespaces
+		}
+
+		for _, namespace := range scopes {
+			factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(client, 0, namespace, tweak)
+			if _, err := factory.ForResource(resource).Informer().AddEventHandler(handler); err != nil {
+				return err
+			}
+			factories = append(factories, factory)
+		}
+	}
+
+	for _, factory := range factories {
+		factory.Start(ctx.Done())
+		factory.WaitForCacheSync(ctx.Done())
+	}
+	synced.Store(true)
+
+	fmt.Fprintf(info, "Watching %d resources for changes\n", len(o.watched))
+
+	for {
+		select {
+		case <-ctx.Done():
+			for _, factory := range factories {
+				factory.Shutdown()
+			}
+			return nil
+		case <-changes:
+		}
+
+		// Further changes within the interval are collected, so the graph is written only once.
+		timer := time.NewTimer(o.WatchInterval)
+	debounce:
+		for {
+			select {
+			case <-changes:
+				if !timer.Stop() {
+					<-timer.C
+				}
+				timer.Reset(o.WatchInterval)
+			case <-timer.C:
+				break debounce
+			case <-ctx.Done():
+				timer.Stop()
+				break debounce
+			}
+		}
+
+		if ctx.Err() != nil {
+			continue
+		}
+
+		if err := o.Generate(ctx, f, args, info); err != nil {
+			fmt.Fprintf(o.ErrOut, "error: %v\n", err)
+		}
+	}
+}
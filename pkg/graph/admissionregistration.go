@@ -0,0 +1,128 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	v1 "k8s.io/api/admissionregistration/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// AdmissionRegistrationV1Graph is used to graph all admission registration resources.
+type AdmissionRegistrationV1Graph struct {
+	graph *Graph
+}
+
+// NewAdmissionRegistrationV1Graph creates a new AdmissionRegistrationV1Graph.
+func NewAdmissionRegistrationV1Graph(g *Graph) *AdmissionRegistrationV1Graph {
+	return &AdmissionRegistrationV1Graph{
+		graph: g,
+	}
+}
+
+// AdmissionRegistrationV1 retrieves the AdmissionRegistrationV1Graph.
+func (g *Graph) AdmissionRegistrationV1() *AdmissionRegistrationV1Graph {
+	return g.admissionRegistrationV1
+}
+
+// Unstructured adds an unstructured node to the Graph.
+func (g *AdmissionRegistrationV1Graph) Unstructured(unstr *unstructured.Unstructured) (*Node, error) {
+	switch unstr.GetKind() {
+	case "MutatingWebhookConfiguration":
+		obj := &v1.MutatingWebhookConfiguration{}
+		if err := FromUnstructured(unstr, obj); err != nil {
+			return nil, err
+		}
+		return g.MutatingWebhookConfiguration(obj)
+	case "ValidatingWebhookConfiguration":
+		obj := &v1.ValidatingWebhookConfiguration{}
+		if err := FromUnstructured(unstr, obj); err != nil {
+			return nil, err
+		}
+		return g.ValidatingWebhookConfiguration(obj)
+	default:
+		return g.graph.Node(unstr.GroupVersionKind(), unstr), nil
+	}
+}
+
+// MutatingWebhookConfiguration adds a v1.MutatingWebhookConfiguration resource to the Graph.
+func (g *AdmissionRegistrationV1Graph) MutatingWebhookConfiguration(obj *v1.MutatingWebhookConfiguration) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	for _, webhook := range obj.Webhooks {
+		if err := g.WebhookService(n, webhook.Name, webhook.ClientConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	return n, nil
+}
+
+// ValidatingWebhookConfiguration adds a v1.ValidatingWebhookConfiguration resource to the Graph.
+func (g *AdmissionRegistrationV1Graph) ValidatingWebhookConfiguration(obj *v1.ValidatingWebhookConfiguration) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	for _, webhook := range obj.Webhooks {
+		if err := g.WebhookService(n, webhook.Name, webhook.ClientConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	return n, nil
+}
+
+// WebhookService relates the v1.Service backing a webhook and its pods to the node, labeled by the name of the webhook.
+// A webhook called by URL has no service, while a service which does not exist or is not allowed to get is added as placeholder.
+func (g *AdmissionRegistrationV1Graph) WebhookService(n *Node, name string, config v1.WebhookClientConfig) error {
+	if config.Service == nil {
+		return nil
+	}
+
+	placeholder := func() {
+		s := g.graph.Reference(schema.FromAPIVersionAndKind(corev1.GroupName, "Service"), config.Service.Namespace, config.Service.Name)
+		g.graph.Relationship(n, name, s)
+	}
+
+	allowed, err := g.graph.Allowed(authorizationv1.ResourceAttributes{Namespace: config.Service.Namespace, Verb: "get", Resource: "services", Name: config.Service.Name})
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		placeholder()
+		return nil
+	}
+
+	options := metav1.GetOptions{}
+	service, err := g.graph.clientset.CoreV1().Services(config.Service.Namespace).Get(g.graph.ctx, config.Service.Name, options)
+	if apierrors.IsNotFound(err) || apierrors.IsForbidden(err) {
+		placeholder()
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	s, err := g.graph.CoreV1().Service(service)
+	if err != nil {
+		return err
+	}
+	g.graph.Relationship(n, name, s)
+
+	return nil
+}
@@ -0,0 +1,50 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWebhookService(t *testing.T) {
+	url := "https://webhook.example.com/validate"
+	obj := &v1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "policy", UID: "configuration"},
+		Webhooks: []v1.ValidatingWebhook{
+			{Name: "url.example.com", ClientConfig: v1.WebhookClientConfig{URL: &url}},
+			{Name: "service.example.com", ClientConfig: v1.WebhookClientConfig{Service: &v1.ServiceReference{Namespace: "policy", Name: "webhook"}}},
+		},
+	}
+
+	// The service of the webhook does not exist, because the server responds with not found.
+	g := newTestGraph(t, map[string]string{})
+	n, err := g.AdmissionRegistrationV1().ValidatingWebhookConfiguration(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(g.RelationshipList()); n != 1 {
+		t.Errorf("expected only the webhook with a service to be related, got %d relationships", n)
+	}
+
+	service := ToUID(corev1.GroupName, "Service", "policy", "webhook")
+	if g.lookup(n.UID, "service.example.com", service) == nil {
+		t.Error("expected the missing service to be related as placeholder")
+	}
+}
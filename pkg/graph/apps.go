@@ -0,0 +1,242 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	v1 "k8s.io/api/apps/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// AppsV1Graph is used to graph all apps resources.
+type AppsV1Graph struct {
+	graph *Graph
+}
+
+// NewAppsV1Graph creates a new AppsV1Graph.
+func NewAppsV1Graph(g *Graph) *AppsV1Graph {
+	return &AppsV1Graph{
+		graph: g,
+	}
+}
+
+// AppsV1 retrieves the AppsV1Graph.
+func (g *Graph) AppsV1() *AppsV1Graph {
+	return g.appsV1
+}
+
+// Unstructured adds an unstructured node to the Graph.
+func (g *AppsV1Graph) Unstructured(unstr *unstructured.Unstructured) (*Node, error) {
+	switch unstr.GetKind() {
+	case "DaemonSet":
+		obj := &v1.DaemonSet{}
+		if err := FromUnstructured(unstr, obj); err != nil {
+			return nil, err
+		}
+		return g.DaemonSet(obj)
+	case "Deployment":
+		obj := &v1.Deployment{}
+		if err := FromUnstructured(unstr, obj); err != nil {
+			return nil, err
+		}
+		return g.Deployment(obj)
+	case "ReplicaSet":
+		obj := &v1.ReplicaSet{}
+		if err := FromUnstructured(unstr, obj); err != nil {
+			return nil, err
+		}
+		return g.ReplicaSet(obj)
+	case "StatefulSet":
+		obj := &v1.StatefulSet{}
+		if err := FromUnstructured(unstr, obj); err != nil {
+			return nil, err
+		}
+		return g.StatefulSet(obj)
+	default:
+		return g.graph.Node(unstr.GroupVersionKind(), unstr), nil
+	}
+}
+
+// DaemonSet adds a v1.DaemonSet resource to the Graph.
+func (g *AppsV1Graph) DaemonSet(obj *v1.DaemonSet) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+	g.graph.CoreV1().PodSpecReferences(n, obj.GetNamespace(), obj.Spec.Template.Spec)
+
+	if err := g.Pods(n, obj, obj.Spec.Selector); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+// Deployment adds a v1.Deployment resource to the Graph.
+// The replica sets created by the deployment are related through their owner references.
+// The pod template references are added even if the deployment is scaled to zero.
+func (g *AppsV1Graph) Deployment(obj *v1.Deployment) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+	g.graph.CoreV1().PodSpecReferences(n, obj.GetNamespace(), obj.Spec.Template.Spec)
+
+	if obj.Spec.Selector == nil {
+		return n, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(obj.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+
+	options := metav1.ListOptions{LabelSelector: selector.String()}
+	replicaSets, err := g.ReplicaSetList(obj.GetNamespace(), options)
+	if err != nil {
+		return nil, err
+	}
+
+	pods := []corev1.Pod{}
+	for i := range replicaSets.Items {
+		if !metav1.IsControlledBy(&replicaSets.Items[i], obj) {
+			continue
+		}
+		rs := g.graph.Node(v1.SchemeGroupVersion.WithKind("ReplicaSet"), &replicaSets.Items[i])
+		controlled, err := g.ControlledPods(rs, &replicaSets.Items[i], replicaSets.Items[i].Spec.Selector)
+		if err != nil {
+			return nil, err
+		}
+		pods = append(pods, controlled...)
+	}
+
+	if err := g.Services(n, obj.GetNamespace(), pods); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+// ReplicaSet adds a v1.ReplicaSet resource to the Graph.
+func (g *AppsV1Graph) ReplicaSet(obj *v1.ReplicaSet) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+	g.graph.CoreV1().PodSpecReferences(n, obj.GetNamespace(), obj.Spec.Template.Spec)
+
+	if err := g.Pods(n, obj, obj.Spec.Selector); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+// StatefulSet adds a v1.StatefulSet resource to the Graph.
+func (g *AppsV1Graph) StatefulSet(obj *v1.StatefulSet) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+	g.graph.CoreV1().PodSpecReferences(n, obj.GetNamespace(), obj.Spec.Template.Spec)
+
+	if err := g.Pods(n, obj, obj.Spec.Selector); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+// Pods adds all v1.Pod resources controlled by a workload to the Graph
+// and relates the workload to the v1.Service resources selecting them.
+func (g *AppsV1Graph) Pods(n *Node, obj metav1.Object, selector *metav1.LabelSelector) error {
+	pods, err := g.ControlledPods(n, obj, selector)
+	if err != nil {
+		return err
+	}
+
+	return g.Services(n, obj.GetNamespace(), pods)
+}
+
+// ControlledPods adds all v1.Pod resources matching the selector and controlled by a workload to the Graph.
+// The pods are related to the workload through their owner references.
+func (g *AppsV1Graph) ControlledPods(n *Node, obj metav1.Object, selector *metav1.LabelSelector) ([]corev1.Pod, error) {
+	if selector == nil {
+		return nil, nil
+	}
+
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	options := metav1.ListOptions{LabelSelector: s.String()}
+	list, err := g.graph.CoreV1().PodList(obj.GetNamespace(), options)
+	if err != nil {
+		return nil, err
+	}
+
+	pods := []corev1.Pod{}
+	for i := range list.Items {
+		if !metav1.IsControlledBy(&list.Items[i], obj) {
+			continue
+		}
+		if _, err := g.graph.CoreV1().Pod(&list.Items[i]); err != nil {
+			return nil, err
+		}
+		pods = append(pods, list.Items[i])
+	}
+
+	return pods, nil
+}
+
+// Services relates all v1.Service resources to a workload, if the service selects all pods of the workload.
+// A workload without pods is not selected by any service.
+func (g *AppsV1Graph) Services(n *Node, namespace string, pods []corev1.Pod) error {
+	if len(pods) == 0 {
+		return nil
+	}
+
+	services, err := g.graph.CoreV1().ServiceList(namespace, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	for i := range services.Items {
+		if len(services.Items[i].Spec.Selector) == 0 {
+			continue
+		}
+
+		selector := labels.SelectorFromSet(services.Items[i].Spec.Selector)
+		selected := true
+		for _, pod := range pods {
+			if !selector.Matches(labels.Set(pod.GetLabels())) {
+				selected = false
+				break
+			}
+		}
+
+		if selected {
+			s := g.graph.Node(schema.FromAPIVersionAndKind(corev1.GroupName, "Service"), &services.Items[i])
+			g.graph.Relationship(s, RelationshipSelects, n)
+		}
+	}
+
+	return nil
+}
+
+// ReplicaSetList lists all v1.ReplicaSet resources within a namespace in chunks of the configured size.
+// An empty list is returned if the current user is not allowed to list replica sets.
+func (g *AppsV1Graph) ReplicaSetList(namespace string, options metav1.ListOptions) (*v1.ReplicaSetList, error) {
+	attr := authorizationv1.ResourceAttributes{Namespace: namespace, Verb: "list", Group: v1.GroupName, Resource: "replicasets"}
+	items, err := listChunks(g.graph, attr, options, g.graph.clientset.AppsV1().ReplicaSets(namespace).List, func(list *v1.ReplicaSetList) []v1.ReplicaSet { return list.Items })
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.ReplicaSetList{Items: items}, nil
+}
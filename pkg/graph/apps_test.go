@@ -0,0 +1,55 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWorkloadReferencesPodTemplate(t *testing.T) {
+	g := newTestGraph(t, map[string]string{
+		"/apis/apps/v1/namespaces/default/replicasets": `{"kind": "ReplicaSetList", "apiVersion": "apps/v1", "items": []}`,
+	})
+
+	replicas := int32(0)
+	obj := &v1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "deployment"},
+		Spec: v1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Volumes: []corev1.Volume{{
+						Name:         "config",
+						VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "web"}}},
+					}},
+				},
+			},
+		},
+	}
+	n, err := g.AppsV1().Deployment(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	configMap := ToUID(corev1.GroupName, "ConfigMap", "default", "web")
+	if g.lookup(n.UID, "volume", configMap) == nil {
+		t.Error("expected the deployment scaled to zero to reference the config map")
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	v2 "k8s.io/api/autoscaling/v2"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// AutoscalingV2Graph is used to graph all autoscaling resources.
+type AutoscalingV2Graph struct {
+	graph *Graph
+}
+
+// NewAutoscalingV2Graph creates a new AutoscalingV2Graph.
+func NewAutoscalingV2Graph(g *Graph) *AutoscalingV2Graph {
+	return &AutoscalingV2Graph{
+		graph: g,
+	}
+}
+
+// AutoscalingV2 retrieves the AutoscalingV2Graph.
+func (g *Graph) AutoscalingV2() *AutoscalingV2Graph {
+	return g.autoscalingV2
+}
+
+// Unstructured adds an unstructured node to the Graph.
+func (g *AutoscalingV2Graph) Unstructured(unstr *unstructured.Unstructured) (*Node, error) {
+	switch unstr.GetKind() {
+	case "HorizontalPodAutoscaler":
+		// The scaleTargetRef of autoscaling/v1 has the same shape as in autoscaling/v2.
+		obj := &v2.HorizontalPodAutoscaler{}
+		if err := FromUnstructured(unstr, obj); err != nil {
+			return nil, err
+		}
+		return g.HorizontalPodAutoscaler(obj)
+	default:
+		return g.graph.Node(unstr.GroupVersionKind(), unstr), nil
+	}
+}
+
+// HorizontalPodAutoscaler adds a v2.HorizontalPodAutoscaler resource to the Graph.
+func (g *AutoscalingV2Graph) HorizontalPodAutoscaler(obj *v2.HorizontalPodAutoscaler) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	ref := obj.Spec.ScaleTargetRef
+	t := g.graph.Reference(schema.FromAPIVersionAndKind(ref.APIVersion, ref.Kind), obj.GetNamespace(), ref.Name)
+	g.graph.Relationship(n, "scaleTargetRef", t)
+
+	return n, nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// BatchV1Graph is used to graph all batch resources.
+type BatchV1Graph struct {
+	graph *Graph
+}
+
+// NewBatchV1Graph creates a new BatchV1Graph.
+func NewBatchV1Graph(g *Graph) *BatchV1Graph {
+	return &BatchV1Graph{
+		graph: g,
+	}
+}
+
+// BatchV1 retrieves the BatchV1Graph.
+func (g *Graph) BatchV1() *BatchV1Graph {
+	return g.batchV1
+}
+
+// Unstructured adds an unstructured node to the Graph.
+func (g *BatchV1Graph) Unstructured(unstr *unstructured.Unstructured) (*Node, error) {
+	switch unstr.GetKind() {
+	case "CronJob":
+		obj := &v1.CronJob{}
+		if err := FromUnstructured(unstr, obj); err != nil {
+			return nil, err
+		}
+		return g.CronJob(obj)
+	case "Job":
+		obj := &v1.Job{}
+		if err := FromUnstructured(unstr, obj); err != nil {
+			return nil, err
+		}
+		return g.Job(obj)
+	default:
+		return g.graph.Node(unstr.GroupVersionKind(), unstr), nil
+	}
+}
+
+// CronJob adds a v1.CronJob resource to the Graph.
+// The jobs created by the cron job are related through their owner references.
+func (g *BatchV1Graph) CronJob(obj *v1.CronJob) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+	g.graph.CoreV1().PodSpecReferences(n, obj.GetNamespace(), obj.Spec.JobTemplate.Spec.Template.Spec)
+
+	jobs, err := g.JobList(obj.GetNamespace(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range jobs.Items {
+		if owner := metav1.GetControllerOf(&jobs.Items[i]); owner == nil || owner.UID != obj.GetUID() {
+			continue
+		}
+		if _, err := g.Job(&jobs.Items[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return n, nil
+}
+
+// Job adds a v1.Job resource to the Graph.
+// The pods created by the job are related through their owner references, including completed pods.
+// A job without a selector or without any pods yet has no relationships to pods.
+func (g *BatchV1Graph) Job(obj *v1.Job) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+	g.graph.CoreV1().PodSpecReferences(n, obj.GetNamespace(), obj.Spec.Template.Spec)
+
+	if obj.Spec.Selector == nil {
+		return n, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(obj.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+
+	options := metav1.ListOptions{LabelSelector: selector.String()}
+	pods, err := g.graph.CoreV1().PodList(obj.GetNamespace(), options)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range pods.Items {
+		if _, err := g.graph.CoreV1().Pod(&pods.Items[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return n, nil
+}
+
+// JobList lists all v1.Job resources within a namespace in chunks of the configured size.
+// An empty list is returned if the current user is not allowed to list jobs.
+func (g *BatchV1Graph) JobList(namespace string, options metav1.ListOptions) (*v1.JobList, error) {
+	attr := authorizationv1.ResourceAttributes{Namespace: namespace, Verb: "list", Group: v1.GroupName, Resource: "jobs"}
+	items, err := listChunks(g.graph, attr, options, g.graph.clientset.BatchV1().Jobs(namespace).List, func(list *v1.JobList) []v1.Job { return list.Items })
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.JobList{Items: items}, nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCronJobReferencesJobTemplate(t *testing.T) {
+	g := newTestGraph(t, map[string]string{
+		"/apis/batch/v1/namespaces/default/jobs": `{"kind": "JobList", "apiVersion": "batch/v1", "items": []}`,
+	})
+
+	obj := &v1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "default", UID: "cronjob"},
+		Spec: v1.CronJobSpec{
+			JobTemplate: v1.JobTemplateSpec{
+				Spec: v1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{
+								Name:    "backup",
+								EnvFrom: []corev1.EnvFromSource{{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "credentials"}}}},
+							}},
+						},
+					},
+				},
+			},
+		},
+	}
+	n, err := g.BatchV1().CronJob(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret := ToUID(corev1.GroupName, "Secret", "default", "credentials")
+	if g.lookup(n.UID, "envFrom", secret) == nil {
+		t.Error("expected the cron job to reference the secret of its job template")
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// CertManagerGroupVersion is the group version of the cert-manager resources.
+var CertManagerGroupVersion = schema.GroupVersion{Group: "cert-manager.io", Version: "v1"}
+
+// CertManagerV1Graph is used to graph all cert-manager resources.
+type CertManagerV1Graph struct {
+	graph *Graph
+}
+
+// NewCertManagerV1Graph creates a new CertManagerV1Graph.
+func NewCertManagerV1Graph(g *Graph) *CertManagerV1Graph {
+	return &CertManagerV1Graph{
+		graph: g,
+	}
+}
+
+// CertManagerV1 retrieves the CertManagerV1Graph.
+func (g *Graph) CertManagerV1() *CertManagerV1Graph {
+	return g.certManagerV1
+}
+
+// Unstructured adds an unstructured node to the Graph.
+// CertificateRequests, Orders and Challenges are related by their owner references.
+func (g *CertManagerV1Graph) Unstructured(unstr *unstructured.Unstructured) (*Node, error) {
+	switch unstr.GetKind() {
+	case "Certificate":
+		return g.Certificate(unstr)
+	case "CertificateRequest":
+		return g.CertificateRequest(unstr)
+	default:
+		return g.graph.Node(unstr.GroupVersionKind(), unstr), nil
+	}
+}
+
+// Certificate adds a Certificate resource to the Graph.
+// The Secret is added even if it was not issued yet.
+func (g *CertManagerV1Graph) Certificate(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	if name, _, _ := unstructured.NestedString(obj.Object, "spec", "secretName"); len(name) != 0 {
+		s := g.graph.Reference(schema.FromAPIVersionAndKind(v1.GroupName, "Secret"), obj.GetNamespace(), name)
+		g.graph.Relationship(n, "Secret", s)
+	}
+
+	g.IssuerRef(n, obj)
+
+	return n, nil
+}
+
+// CertificateRequest adds a CertificateRequest resource to the Graph.
+func (g *CertManagerV1Graph) CertificateRequest(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	g.IssuerRef(n, obj)
+
+	return n, nil
+}
+
+// IssuerRef adds the Issuer or ClusterIssuer referenced by the issuerRef field to the Graph.
+func (g *CertManagerV1Graph) IssuerRef(n *Node, obj *unstructured.Unstructured) *Node {
+	name, _, _ := unstructured.NestedString(obj.Object, "spec", "issuerRef", "name")
+	if len(name) == 0 {
+		return nil
+	}
+
+	kind, _, _ := unstructured.NestedString(obj.Object, "spec", "issuerRef", "kind")
+	if len(kind) == 0 {
+		kind = "Issuer"
+	}
+
+	gvk := CertManagerGroupVersion.WithKind(kind)
+	if group, _, _ := unstructured.NestedString(obj.Object, "spec", "issuerRef", "group"); len(group) != 0 {
+		gvk.Group = group
+	}
+
+	namespace := obj.GetNamespace()
+	if kind == "ClusterIssuer" {
+		namespace = ""
+	}
+
+	i := g.graph.Reference(gvk, namespace, name)
+	g.graph.Relationship(n, kind, i)
+
+	return i
+}
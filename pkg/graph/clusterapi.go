@@ -0,0 +1,113 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ClusterAPIGroupVersion is the group version of the cluster api resources.
+var ClusterAPIGroupVersion = schema.GroupVersion{Group: "cluster.x-k8s.io", Version: "v1beta1"}
+
+// ClusterAPIV1Graph is used to graph all cluster api resources.
+type ClusterAPIV1Graph struct {
+	graph *Graph
+}
+
+// NewClusterAPIV1Graph creates a new ClusterAPIV1Graph.
+func NewClusterAPIV1Graph(g *Graph) *ClusterAPIV1Graph {
+	return &ClusterAPIV1Graph{
+		graph: g,
+	}
+}
+
+// ClusterAPIV1 retrieves the ClusterAPIV1Graph.
+func (g *Graph) ClusterAPIV1() *ClusterAPIV1Graph {
+	return g.clusterAPIV1
+}
+
+// Unstructured adds an unstructured node to the Graph.
+// The MachineDeployments, MachineSets and Machines are related to their owners by the owner references.
+func (g *ClusterAPIV1Graph) Unstructured(unstr *unstructured.Unstructured) (*Node, error) {
+	switch unstr.GetKind() {
+	case "Cluster":
+		return g.Cluster(unstr)
+	case "MachineDeployment", "MachineSet":
+		return g.MachineTemplate(unstr)
+	case "Machine":
+		return g.Machine(unstr)
+	default:
+		return g.graph.Node(unstr.GroupVersionKind(), unstr), nil
+	}
+}
+
+// Cluster adds a Cluster resource to the Graph, which is related to its control plane and infrastructure cluster.
+func (g *ClusterAPIV1Graph) Cluster(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	g.ObjectReference(n, obj, "controlPlaneRef", "spec", "controlPlaneRef")
+	g.ObjectReference(n, obj, "infrastructureRef", "spec", "infrastructureRef")
+
+	return n, nil
+}
+
+// MachineTemplate adds a MachineDeployment or MachineSet resource to the Graph,
+// which is related to the infrastructure and bootstrap templates of its machines.
+func (g *ClusterAPIV1Graph) MachineTemplate(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	g.ObjectReference(n, obj, "infrastructureRef", "spec", "template", "spec", "infrastructureRef")
+	g.ObjectReference(n, obj, "configRef", "spec", "template", "spec", "bootstrap", "configRef")
+
+	return n, nil
+}
+
+// Machine adds a Machine resource to the Graph, which is related to its infrastructure machine and bootstrap config.
+func (g *ClusterAPIV1Graph) Machine(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	g.ObjectReference(n, obj, "infrastructureRef", "spec", "infrastructureRef")
+	g.ObjectReference(n, obj, "configRef", "spec", "bootstrap", "configRef")
+
+	return n, nil
+}
+
+// ObjectReference relates the node to the resource referenced by the field of the object.
+// The reference is resolved by apiVersion, or by apiGroup since v1beta2, within the namespace of the object.
+func (g *ClusterAPIV1Graph) ObjectReference(n *Node, obj *unstructured.Unstructured, label string, fields ...string) *Node {
+	ref, ok, _ := unstructured.NestedMap(obj.Object, fields...)
+	if !ok {
+		return nil
+	}
+
+	apiVersion, _, _ := unstructured.NestedString(ref, "apiVersion")
+	apiGroup, _, _ := unstructured.NestedString(ref, "apiGroup")
+	kind, _, _ := unstructured.NestedString(ref, "kind")
+	name, _, _ := unstructured.NestedString(ref, "name")
+	if len(kind) == 0 || len(name) == 0 {
+		return nil
+	}
+
+	gvk := schema.GroupVersionKind{Group: apiGroup, Kind: kind}
+	if len(apiVersion) != 0 {
+		gvk = schema.FromAPIVersionAndKind(apiVersion, kind)
+	}
+
+	r := g.graph.Reference(gvk, obj.GetNamespace(), name)
+	g.graph.Relationship(n, label, r)
+
+	return r
+}
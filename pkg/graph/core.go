@@ -15,12 +15,15 @@
 package graph
 
 import (
-	"context"
 	"strings"
 
+	authorizationv1 "k8s.io/api/authorization/v1"
 	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
@@ -56,6 +59,36 @@ func (g *CoreV1Graph) Unstructured(unstr *unstructured.Unstructured) (*Node, err
 			return nil, err
 		}
 		return g.Pod(obj)
+	case "PersistentVolumeClaim":
+		obj := &v1.PersistentVolumeClaim{}
+		if err := FromUnstructured(unstr, obj); err != nil {
+			return nil, err
+		}
+		return g.PersistentVolumeClaim(obj)
+	case "PersistentVolume":
+		obj := &v1.PersistentVolume{}
+		if err := FromUnstructured(unstr, obj); err != nil {
+			return nil, err
+		}
+		return g.PersistentVolume(obj)
+	case "Secret":
+		obj := &v1.Secret{}
+		if err := FromUnstructured(unstr, obj); err != nil {
+			return nil, err
+		}
+		return g.Secret(obj)
+	case "ServiceAccount":
+		obj := &v1.ServiceAccount{}
+		if err := FromUnstructured(unstr, obj); err != nil {
+			return nil, err
+		}
+		return g.ServiceAccount(obj)
+	case "Event":
+		obj := &v1.Event{}
+		if err := FromUnstructured(unstr, obj); err != nil {
+			return nil, err
+		}
+		return g.Event(obj)
 	case "Endpoints":
 		obj := &v1.Endpoints{}
 		if err := FromUnstructured(unstr, obj); err != nil {
@@ -133,9 +166,126 @@ func (g *CoreV1Graph) Pod(pod *v1.Pod) (*Node, error) {
 		g.graph.Relationship(n, "Container", c)
 	}
 
+	g.PodSpecReferences(n, pod.GetNamespace(), pod.Spec)
+
 	return n, nil
 }
 
+// podSpecReference represents a v1.ConfigMap, v1.Secret or v1.ServiceAccount referenced by a v1.PodSpec.
+type podSpecReference struct {
+	kind  string
+	name  string
+	label string
+}
+
+// PodSpecReferences adds all v1.ConfigMap, v1.Secret and v1.ServiceAccount resources referenced by a v1.PodSpec to the Graph.
+func (g *CoreV1Graph) PodSpecReferences(n *Node, namespace string, spec v1.PodSpec) {
+	refs := []podSpecReference{}
+
+	for _, volume := range spec.Volumes {
+		switch {
+		case volume.ConfigMap != nil:
+			refs = append(refs, podSpecReference{"ConfigMap", volume.ConfigMap.Name, "volume"})
+		case volume.Secret != nil:
+			refs = append(refs, podSpecReference{"Secret", volume.Secret.SecretName, "volume"})
+		case volume.Projected != nil:
+			for _, source := range volume.Projected.Sources {
+				if source.ConfigMap != nil {
+					refs = append(refs, podSpecReference{"ConfigMap", source.ConfigMap.Name, "volume"})
+				}
+				if source.Secret != nil {
+					refs = append(refs, podSpecReference{"Secret", source.Secret.Name, "volume"})
+				}
+			}
+		}
+	}
+
+	containers := append(append([]v1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, container := range containers {
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.ConfigMapRef != nil {
+				refs = append(refs, podSpecReference{"ConfigMap", envFrom.ConfigMapRef.Name, "envFrom"})
+			}
+			if envFrom.SecretRef != nil {
+				refs = append(refs, podSpecReference{"Secret", envFrom.SecretRef.Name, "envFrom"})
+			}
+		}
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if env.ValueFrom.ConfigMapKeyRef != nil {
+				refs = append(refs, podSpecReference{"ConfigMap", env.ValueFrom.ConfigMapKeyRef.Name, "env"})
+			}
+			if env.ValueFrom.SecretKeyRef != nil {
+				refs = append(refs, podSpecReference{"Secret", env.ValueFrom.SecretKeyRef.Name, "env"})
+			}
+		}
+	}
+
+	for _, imagePullSecret := range spec.ImagePullSecrets {
+		refs = append(refs, podSpecReference{"Secret", imagePullSecret.Name, "imagePullSecret"})
+	}
+
+	refs = append(refs, podSpecReference{"ServiceAccount", spec.ServiceAccountName, "serviceAccount"})
+
+	for _, ref := range refs {
+		if len(ref.name) == 0 {
+			continue
+		}
+		r := g.graph.Reference(schema.FromAPIVersionAndKind(v1.GroupName, ref.kind), namespace, ref.name)
+		g.graph.Relationship(n, ref.label, r)
+	}
+}
+
+// PodList lists all v1.Pod resources within a namespace in chunks of the configured size.
+// An empty list is returned if the current user is not allowed to list pods.
+func (g *CoreV1Graph) PodList(namespace string, options metav1.ListOptions) (*v1.PodList, error) {
+	attr := authorizationv1.ResourceAttributes{Namespace: namespace, Verb: "list", Resource: "pods"}
+	items, err := listChunks(g.graph, attr, options, g.graph.clientset.CoreV1().Pods(namespace).List, func(list *v1.PodList) []v1.Pod { return list.Items })
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.PodList{Items: items}, nil
+}
+
+// NamespaceList lists all v1.Namespace resources in chunks of the configured size.
+// An empty list is returned if the current user is not allowed to list namespaces.
+func (g *CoreV1Graph) NamespaceList(options metav1.ListOptions) (*v1.NamespaceList, error) {
+	attr := authorizationv1.ResourceAttributes{Verb: "list", Resource: "namespaces"}
+	items, err := listChunks(g.graph, attr, options, g.graph.clientset.CoreV1().Namespaces().List, func(list *v1.NamespaceList) []v1.Namespace { return list.Items })
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.NamespaceList{Items: items}, nil
+}
+
+// NodeList lists all v1.Node resources in chunks of the configured size.
+// An empty list is returned if the current user is not allowed to list nodes.
+func (g *CoreV1Graph) NodeList(options metav1.ListOptions) (*v1.NodeList, error) {
+	attr := authorizationv1.ResourceAttributes{Verb: "list", Resource: "nodes"}
+	items, err := listChunks(g.graph, attr, options, g.graph.clientset.CoreV1().Nodes().List, func(list *v1.NodeList) []v1.Node { return list.Items })
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.NodeList{Items: items}, nil
+}
+
+// ServiceList lists all v1.Service resources within a namespace in chunks of the configured size.
+// An empty list is returned if the current user is not allowed to list services.
+func (g *CoreV1Graph) ServiceList(namespace string, options metav1.ListOptions) (*v1.ServiceList, error) {
+	attr := authorizationv1.ResourceAttributes{Namespace: namespace, Verb: "list", Resource: "services"}
+	items, err := listChunks(g.graph, attr, options, g.graph.clientset.CoreV1().Services(namespace).List, func(list *v1.ServiceList) []v1.Service { return list.Items })
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.ServiceList{Items: items}, nil
+}
+
 // Container adds a v1.Container resource to the Graph.
 func (g *CoreV1Graph) Container(pod *v1.Pod, container v1.Container) (*Node, error) {
 	n := g.graph.Node(
@@ -198,6 +348,96 @@ func (g *CoreV1Graph) Registry(name string) (*Node, error) {
 	return n, nil
 }
 
+// PersistentVolumeClaim adds a v1.PersistentVolumeClaim resource to the Graph.
+func (g *CoreV1Graph) PersistentVolumeClaim(obj *v1.PersistentVolumeClaim) (*Node, error) {
+	n := g.graph.Node(schema.FromAPIVersionAndKind(v1.GroupName, "PersistentVolumeClaim"), obj)
+
+	// A pending claim is not bound to a volume yet.
+	if len(obj.Spec.VolumeName) != 0 {
+		pv := g.graph.Reference(schema.FromAPIVersionAndKind(v1.GroupName, "PersistentVolume"), "", obj.Spec.VolumeName)
+		g.graph.Relationship(n, "bound", pv)
+	}
+
+	return n, nil
+}
+
+// PersistentVolume adds a v1.PersistentVolume resource to the Graph.
+func (g *CoreV1Graph) PersistentVolume(obj *v1.PersistentVolume) (*Node, error) {
+	n := g.graph.Node(schema.FromAPIVersionAndKind(v1.GroupName, "PersistentVolume"), obj)
+
+	if len(obj.Spec.StorageClassName) != 0 {
+		sc := g.graph.Reference(storagev1.SchemeGroupVersion.WithKind("StorageClass"), "", obj.Spec.StorageClassName)
+		g.graph.Relationship(n, "storageclass", sc)
+	}
+
+	return n, nil
+}
+
+// Secret adds a v1.Secret resource to the Graph.
+// Secrets storing a helm release are related to a HelmRelease and the resources of its manifest.
+func (g *CoreV1Graph) Secret(obj *v1.Secret) (*Node, error) {
+	n := g.graph.Node(schema.FromAPIVersionAndKind(v1.GroupName, "Secret"), obj)
+
+	if _, err := g.graph.HelmV3().ReleaseSecret(n, obj); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+// ServiceAccount adds a v1.ServiceAccount resource to the Graph.
+// Since Kubernetes 1.24 token secrets are no longer created automatically, so the list of secrets is usually empty.
+func (g *CoreV1Graph) ServiceAccount(obj *v1.ServiceAccount) (*Node, error) {
+	n := g.graph.Node(schema.FromAPIVersionAndKind(v1.GroupName, "ServiceAccount"), obj)
+
+	for _, secret := range obj.Secrets {
+		if len(secret.Name) == 0 {
+			continue
+		}
+		namespace := obj.GetNamespace()
+		if len(secret.Namespace) != 0 {
+			namespace = secret.Namespace
+		}
+		s := g.graph.Reference(schema.FromAPIVersionAndKind(v1.GroupName, "Secret"), namespace, secret.Name)
+		g.graph.Relationship(n, "secret", s)
+	}
+
+	for _, imagePullSecret := range obj.ImagePullSecrets {
+		if len(imagePullSecret.Name) == 0 {
+			continue
+		}
+		s := g.graph.Reference(schema.FromAPIVersionAndKind(v1.GroupName, "Secret"), obj.GetNamespace(), imagePullSecret.Name)
+		g.graph.Relationship(n, "imagePullSecret", s)
+	}
+
+	return n, nil
+}
+
+// Event adds a v1.Event resource to the Graph, which is related to its involved object labeled with the reason.
+// Warning events are colored red.
+func (g *CoreV1Graph) Event(obj *v1.Event) (*Node, error) {
+	n := g.graph.Node(schema.FromAPIVersionAndKind(v1.GroupName, "Event"), obj)
+
+	ref := obj.InvolvedObject
+	if len(ref.Kind) == 0 || len(ref.Name) == 0 {
+		return n, nil
+	}
+
+	var o *Node
+	if len(ref.UID) != 0 {
+		o = g.graph.Node(ref.GroupVersionKind(), &metav1.ObjectMeta{UID: ref.UID, Name: ref.Name, Namespace: ref.Namespace})
+	} else {
+		o = g.graph.Reference(ref.GroupVersionKind(), ref.Namespace, ref.Name)
+	}
+
+	r := g.graph.Relationship(n, obj.Reason, o)
+	if obj.Type == v1.EventTypeWarning {
+		r.Attribute("color", "#ea4335")
+	}
+
+	return n, nil
+}
+
 // Endpoints adds a v1.Endpoints resource to the Graph.
 func (g *CoreV1Graph) Endpoints(obj *v1.Endpoints) (*Node, error) {
 	n := g.graph.Node(schema.FromAPIVersionAndKind(v1.GroupName, "Endpoints"), obj)
@@ -209,7 +449,7 @@ func (g *CoreV1Graph) Endpoints(obj *v1.Endpoints) (*Node, error) {
 				if err != nil {
 					return nil, err
 				}
-				g.graph.Relationship(n, t.Kind, t)
+				g.graph.Relationship(n, RelationshipReferences, t)
 			}
 		}
 	}
@@ -246,62 +486,74 @@ func (g *CoreV1Graph) TypedLocalObjectReference(obj *v1.TypedLocalObjectReferenc
 }
 
 // Service adds a v1.Service resource to the Graph.
+// Every service is related to the pods of its selector, while the other relationships depend on the service type.
 func (g *CoreV1Graph) Service(obj *v1.Service) (*Node, error) {
+	n := g.graph.Node(schema.FromAPIVersionAndKind(v1.GroupName, "Service"), obj)
+
 	switch obj.Spec.Type {
-	case v1.ServiceTypeClusterIP:
-		return g.ServiceTypeClusterIP(obj)
-		// case v1.ServiceTypeNodePort:
-	case v1.ServiceTypeLoadBalancer:
-		return g.ServiceTypeLoadBalancer(obj)
 	case v1.ServiceTypeExternalName:
-		return g.ServiceTypeExternalName(obj)
+		g.ServiceTypeExternalName(obj, n)
+	default:
+		if err := g.ServiceEndpoints(obj, n); err != nil {
+			return nil, err
+		}
 	}
 
-	return nil, nil
-}
+	if err := g.ServiceSelector(obj, n); err != nil {
+		return nil, err
+	}
 
-// ServiceTypeClusterIP adds a v1.Service of type ClusterIP to the Graph.
-func (g *CoreV1Graph) ServiceTypeClusterIP(obj *v1.Service) (*Node, error) {
-	n := g.graph.Node(schema.FromAPIVersionAndKind(v1.GroupName, "Service"), obj)
+	return n, nil
+}
 
+// ServiceEndpoints adds the v1.Endpoints of a v1.Service of type ClusterIP, NodePort or LoadBalancer to the Graph.
+// Services without endpoints, like manifests which are not applied yet, are not related to any.
+func (g *CoreV1Graph) ServiceEndpoints(obj *v1.Service, n *Node) error {
 	options := metav1.GetOptions{}
-	endpoints, err := g.graph.clientset.CoreV1().Endpoints(obj.GetNamespace()).Get(context.TODO(), obj.GetName(), options)
+	endpoints, err := g.graph.clientset.CoreV1().Endpoints(obj.GetNamespace()).Get(g.graph.ctx, obj.GetName(), options)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	e, err := g.Endpoints(endpoints)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	g.graph.Relationship(n, "Endpoints", e)
 
-	return n, nil
+	return nil
 }
 
-// ServiceTypeLoadBalancer adds a v1.Service of type LoadBalancer to the Graph.
-func (g *CoreV1Graph) ServiceTypeLoadBalancer(obj *v1.Service) (*Node, error) {
-	n := g.graph.Node(schema.FromAPIVersionAndKind(v1.GroupName, "Service"), obj)
+// ServiceSelector adds all v1.Pod resources selected by a v1.Service to the Graph.
+// Services without a selector, like headless or ExternalName services, have no pods.
+func (g *CoreV1Graph) ServiceSelector(obj *v1.Service, n *Node) error {
+	if len(obj.Spec.Selector) == 0 {
+		return nil
+	}
 
-	options := metav1.GetOptions{}
-	endpoints, err := g.graph.clientset.CoreV1().Endpoints(obj.GetNamespace()).Get(context.TODO(), obj.GetName(), options)
+	selector := labels.SelectorFromSet(obj.Spec.Selector)
+	options := metav1.ListOptions{LabelSelector: selector.String()}
+	pods, err := g.PodList(obj.GetNamespace(), options)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	e, err := g.Endpoints(endpoints)
-	if err != nil {
-		return nil, err
+	for i := range pods.Items {
+		p, err := g.Pod(&pods.Items[i])
+		if err != nil {
+			return err
+		}
+		g.graph.RelationshipUndirected(n, RelationshipSelects, p)
 	}
-	g.graph.Relationship(n, "Endpoints", e)
 
-	return n, nil
+	return nil
 }
 
-// ServiceTypeExternalName adds a v1.Service of type ExternalName to the Graph.
-func (g *CoreV1Graph) ServiceTypeExternalName(obj *v1.Service) (*Node, error) {
-	n := g.graph.Node(schema.FromAPIVersionAndKind(v1.GroupName, "Service"), obj)
-
+// ServiceTypeExternalName relates a v1.Service of type ExternalName to its external name.
+func (g *CoreV1Graph) ServiceTypeExternalName(obj *v1.Service, n *Node) {
 	e := g.graph.Node(
 		schema.FromAPIVersionAndKind(v1.GroupName, "ExternalName"),
 		&metav1.ObjectMeta{
@@ -310,14 +562,29 @@ func (g *CoreV1Graph) ServiceTypeExternalName(obj *v1.Service) (*Node, error) {
 		},
 	)
 	g.graph.Relationship(n, "ExternalName", e)
-
-	return n, nil
 }
 
 // Node adds a v1.Node resource to the Graph.
+// If enabled by the options, the node is related to all running pods scheduled on it.
 func (g *CoreV1Graph) Node(obj *v1.Node) (*Node, error) {
 	n := g.graph.Node(obj.GroupVersionKind(), obj)
 
+	if g.graph.Options.NodePods {
+		options := metav1.ListOptions{FieldSelector: "spec.nodeName=" + obj.GetName() + ",status.phase=Running"}
+		pods, err := g.graph.CoreV1().PodList(metav1.NamespaceAll, options)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range pods.Items {
+			p, err := g.graph.CoreV1().Pod(&pods.Items[i])
+			if err != nil {
+				return nil, err
+			}
+			g.graph.Relationship(n, "runs", p)
+		}
+	}
+
 	infos := map[string]string{
 		"Architecture": obj.Status.NodeInfo.Architecture,
 		"Runtime":      obj.Status.NodeInfo.ContainerRuntimeVersion,
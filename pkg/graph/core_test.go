@@ -0,0 +1,52 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestServiceRelatesSelectedPods(t *testing.T) {
+	for _, serviceType := range []v1.ServiceType{v1.ServiceTypeClusterIP, v1.ServiceTypeNodePort, v1.ServiceTypeLoadBalancer} {
+		t.Run(string(serviceType), func(t *testing.T) {
+			g := newTestGraph(t, map[string]string{
+				"/api/v1/namespaces/default/endpoints/web": `{"kind": "Endpoints", "apiVersion": "v1", "metadata": {"name": "web", "namespace": "default", "uid": "endpoints"}}`,
+				"/api/v1/namespaces/default/pods":          `{"kind": "PodList", "apiVersion": "v1", "items": [{"metadata": {"name": "web-1", "namespace": "default", "uid": "pod"}}]}`,
+			})
+
+			obj := &v1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "service"},
+				Spec:       v1.ServiceSpec{Type: serviceType, Selector: map[string]string{"app": "web"}},
+			}
+			n, err := g.CoreV1().Service(obj)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n == nil || g.Nodes[n.UID] == nil {
+				t.Fatal("expected the service node to be added")
+			}
+
+			if g.lookup(n.UID, RelationshipSelects, "pod") == nil {
+				t.Error("expected the service to select the pod")
+			}
+			if g.lookup(n.UID, "Endpoints", "endpoints") == nil {
+				t.Error("expected the service to be related to its endpoints")
+			}
+		})
+	}
+}
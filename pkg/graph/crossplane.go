@@ -0,0 +1,123 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// CrossplaneGroupVersion is the group version of the crossplane composition resources.
+var CrossplaneGroupVersion = schema.GroupVersion{Group: "apiextensions.crossplane.io", Version: "v1"}
+
+// CrossplaneV1Graph is used to graph all crossplane resources.
+// Claims and composite resources are defined by the user in any group, so they are identified by their spec.
+type CrossplaneV1Graph struct {
+	graph *Graph
+}
+
+// NewCrossplaneV1Graph creates a new CrossplaneV1Graph.
+func NewCrossplaneV1Graph(g *Graph) *CrossplaneV1Graph {
+	return &CrossplaneV1Graph{
+		graph: g,
+	}
+}
+
+// CrossplaneV1 retrieves the CrossplaneV1Graph.
+func (g *Graph) CrossplaneV1() *CrossplaneV1Graph {
+	return g.crossplaneV1
+}
+
+// IsCrossplaneResource returns true if the object looks like a crossplane claim or composite resource.
+// Crossplane sets the compositionRef of both, but a spec.resourceRef alone is too common to identify a claim.
+func IsCrossplaneResource(unstr *unstructured.Unstructured) bool {
+	for _, field := range []string{"compositionRef", "resourceRefs"} {
+		if _, ok, _ := unstructured.NestedFieldNoCopy(unstr.Object, "spec", field); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Unstructured adds an unstructured node to the Graph.
+func (g *CrossplaneV1Graph) Unstructured(unstr *unstructured.Unstructured) (*Node, error) {
+	if _, ok, _ := unstructured.NestedMap(unstr.Object, "spec", "resourceRef"); ok {
+		return g.Claim(unstr)
+	}
+
+	return g.Composite(unstr)
+}
+
+// Claim adds a claim resource to the Graph, which is related to its composite resource.
+func (g *CrossplaneV1Graph) Claim(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	ref, _, _ := unstructured.NestedMap(obj.Object, "spec", "resourceRef")
+	if c := g.ObjectReference(ref); c != nil {
+		g.graph.Relationship(n, "resourceRef", c)
+	}
+
+	g.CompositionRef(n, obj)
+
+	return n, nil
+}
+
+// Composite adds a composite resource to the Graph, which is related to its managed resources and composition.
+func (g *CrossplaneV1Graph) Composite(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	refs, _, _ := unstructured.NestedSlice(obj.Object, "spec", "resourceRefs")
+	for _, ref := range refs {
+		r, ok := ref.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if m := g.ObjectReference(r); m != nil {
+			g.graph.Relationship(n, "resourceRef", m)
+		}
+	}
+
+	g.CompositionRef(n, obj)
+
+	return n, nil
+}
+
+// CompositionRef adds the Composition referenced by spec.compositionRef to the Graph.
+func (g *CrossplaneV1Graph) CompositionRef(n *Node, obj *unstructured.Unstructured) *Node {
+	name, _, _ := unstructured.NestedString(obj.Object, "spec", "compositionRef", "name")
+	if len(name) == 0 {
+		return nil
+	}
+
+	c := g.graph.Reference(CrossplaneGroupVersion.WithKind("Composition"), "", name)
+	g.graph.Relationship(n, "compositionRef", c)
+
+	return c
+}
+
+// ObjectReference adds the resource referenced by apiVersion, kind, name and an optional namespace to the Graph.
+// Composite and managed resources are cluster-scoped unless the reference has a namespace.
+func (g *CrossplaneV1Graph) ObjectReference(ref map[string]interface{}) *Node {
+	apiVersion, _, _ := unstructured.NestedString(ref, "apiVersion")
+	kind, _, _ := unstructured.NestedString(ref, "kind")
+	name, _, _ := unstructured.NestedString(ref, "name")
+	namespace, _, _ := unstructured.NestedString(ref, "namespace")
+	if len(kind) == 0 || len(name) == 0 {
+		return nil
+	}
+
+	return g.graph.Reference(schema.FromAPIVersionAndKind(apiVersion, kind), namespace, name)
+}
@@ -0,0 +1,80 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ExternalSecretsV1Graph is used to graph all external-secrets resources.
+type ExternalSecretsV1Graph struct {
+	graph *Graph
+}
+
+// NewExternalSecretsV1Graph creates a new ExternalSecretsV1Graph.
+func NewExternalSecretsV1Graph(g *Graph) *ExternalSecretsV1Graph {
+	return &ExternalSecretsV1Graph{
+		graph: g,
+	}
+}
+
+// ExternalSecretsV1 retrieves the ExternalSecretsV1Graph.
+func (g *Graph) ExternalSecretsV1() *ExternalSecretsV1Graph {
+	return g.externalSecretsV1
+}
+
+// Unstructured adds an unstructured node to the Graph.
+func (g *ExternalSecretsV1Graph) Unstructured(unstr *unstructured.Unstructured) (*Node, error) {
+	switch unstr.GetKind() {
+	case "ExternalSecret":
+		return g.ExternalSecret(unstr)
+	default:
+		return g.graph.Node(unstr.GroupVersionKind(), unstr), nil
+	}
+}
+
+// ExternalSecret adds an ExternalSecret resource to the Graph, which is related to its store and target Secret.
+// The target Secret is added even if it is not synced yet, so missing secrets are visible.
+func (g *ExternalSecretsV1Graph) ExternalSecret(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+	gv := obj.GroupVersionKind().GroupVersion()
+
+	if name, _, _ := unstructured.NestedString(obj.Object, "spec", "secretStoreRef", "name"); len(name) != 0 {
+		kind, _, _ := unstructured.NestedString(obj.Object, "spec", "secretStoreRef", "kind")
+		namespace := obj.GetNamespace()
+		switch kind {
+		case "ClusterSecretStore":
+			namespace = ""
+		case "":
+			kind = "SecretStore"
+		}
+
+		s := g.graph.Reference(gv.WithKind(kind), namespace, name)
+		g.graph.Relationship(n, "secretStoreRef", s)
+	}
+
+	// The target name defaults to the name of the external secret.
+	name, _, _ := unstructured.NestedString(obj.Object, "spec", "target", "name")
+	if len(name) == 0 {
+		name = obj.GetName()
+	}
+
+	s := g.graph.Reference(schema.FromAPIVersionAndKind(v1.GroupName, "Secret"), obj.GetNamespace(), name)
+	g.graph.Relationship(n, "target", s)
+
+	return n, nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	// FluxKustomizeGroupVersion is the group version of the flux kustomize controller.
+	FluxKustomizeGroupVersion = schema.GroupVersion{Group: "kustomize.toolkit.fluxcd.io", Version: "v1"}
+	// FluxHelmGroupVersion is the group version of the flux helm controller.
+	FluxHelmGroupVersion = schema.GroupVersion{Group: "helm.toolkit.fluxcd.io", Version: "v2"}
+	// FluxSourceGroupVersion is the group version of the flux source controller.
+	FluxSourceGroupVersion = schema.GroupVersion{Group: "source.toolkit.fluxcd.io", Version: "v1"}
+)
+
+// FluxV1Graph is used to graph all flux resources.
+type FluxV1Graph struct {
+	graph *Graph
+}
+
+// NewFluxV1Graph creates a new FluxV1Graph.
+func NewFluxV1Graph(g *Graph) *FluxV1Graph {
+	return &FluxV1Graph{
+		graph: g,
+	}
+}
+
+// FluxV1 retrieves the FluxV1Graph.
+func (g *Graph) FluxV1() *FluxV1Graph {
+	return g.fluxV1
+}
+
+// Unstructured adds an unstructured node to the Graph.
+func (g *FluxV1Graph) Unstructured(unstr *unstructured.Unstructured) (*Node, error) {
+	switch unstr.GetKind() {
+	case "Kustomization":
+		return g.Kustomization(unstr)
+	case "HelmRelease":
+		return g.HelmRelease(unstr)
+	default:
+		return g.graph.Node(unstr.GroupVersionKind(), unstr), nil
+	}
+}
+
+// Kustomization adds a Kustomization resource to the Graph.
+func (g *FluxV1Graph) Kustomization(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	if sourceRef, ok, _ := unstructured.NestedMap(obj.Object, "spec", "sourceRef"); ok {
+		g.SourceRef(n, obj.GetNamespace(), sourceRef)
+	}
+
+	return n, nil
+}
+
+// HelmRelease adds a HelmRelease resource to the Graph.
+func (g *FluxV1Graph) HelmRelease(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	if sourceRef, ok, _ := unstructured.NestedMap(obj.Object, "spec", "chart", "spec", "sourceRef"); ok {
+		g.SourceRef(n, obj.GetNamespace(), sourceRef)
+	}
+	if chartRef, ok, _ := unstructured.NestedMap(obj.Object, "spec", "chartRef"); ok {
+		g.SourceRef(n, obj.GetNamespace(), chartRef)
+	}
+
+	return n, nil
+}
+
+// SourceRef adds the source referenced by a Kustomization or HelmRelease to the Graph.
+func (g *FluxV1Graph) SourceRef(n *Node, namespace string, ref map[string]interface{}) *Node {
+	kind, _, _ := unstructured.NestedString(ref, "kind")
+	name, _, _ := unstructured.NestedString(ref, "name")
+	if len(kind) == 0 || len(name) == 0 {
+		return nil
+	}
+
+	if ns, _, _ := unstructured.NestedString(ref, "namespace"); len(ns) != 0 {
+		namespace = ns
+	}
+
+	s := g.graph.Reference(FluxSourceGroupVersion.WithKind(kind), namespace, name)
+	g.graph.Relationship(n, "sourceRef", s)
+
+	return s
+}
+
+// Managed adds a relationship from the Kustomization or HelmRelease which manages a node,
+// identified by the name and namespace labels set by the flux controllers.
+func (g *FluxV1Graph) Managed(n *Node) {
+	controllers := map[string]schema.GroupVersionKind{
+		FluxKustomizeGroupVersion.Group: FluxKustomizeGroupVersion.WithKind("Kustomization"),
+		FluxHelmGroupVersion.Group:      FluxHelmGroupVersion.WithKind("HelmRelease"),
+	}
+
+	labels := n.GetLabels()
+	for group, gvk := range controllers {
+		name, namespace := labels[group+"/name"], labels[group+"/namespace"]
+		if len(name) == 0 || len(namespace) == 0 {
+			continue
+		}
+
+		r := g.graph.Reference(gvk, namespace, name)
+		g.graph.Relationship(r, "manages", n)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GatewayGroupVersion is the group version of the gateway api resources.
+var GatewayGroupVersion = schema.GroupVersion{Group: "gateway.networking.k8s.io", Version: "v1"}
+
+// GatewayV1Graph is used to graph all gateway api resources.
+type GatewayV1Graph struct {
+	graph *Graph
+}
+
+// NewGatewayV1Graph creates a new GatewayV1Graph.
+func NewGatewayV1Graph(g *Graph) *GatewayV1Graph {
+	return &GatewayV1Graph{
+		graph: g,
+	}
+}
+
+// GatewayV1 retrieves the GatewayV1Graph.
+func (g *Graph) GatewayV1() *GatewayV1Graph {
+	return g.gatewayV1
+}
+
+// Unstructured adds an unstructured node to the Graph.
+func (g *GatewayV1Graph) Unstructured(unstr *unstructured.Unstructured) (*Node, error) {
+	switch unstr.GetKind() {
+	case "Gateway":
+		return g.Gateway(unstr)
+	case "HTTPRoute", "GRPCRoute":
+		return g.Route(unstr)
+	default:
+		return g.graph.Node(unstr.GroupVersionKind(), unstr), nil
+	}
+}
+
+// Gateway adds a Gateway resource to the Graph.
+func (g *GatewayV1Graph) Gateway(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	if name, _, _ := unstructured.NestedString(obj.Object, "spec", "gatewayClassName"); len(name) != 0 {
+		c := g.graph.Reference(GatewayGroupVersion.WithKind("GatewayClass"), "", name)
+		g.graph.Relationship(n, "GatewayClass", c)
+	}
+
+	return n, nil
+}
+
+// Route adds a HTTPRoute or GRPCRoute resource to the Graph.
+func (g *GatewayV1Graph) Route(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	parentRefs, _, _ := unstructured.NestedSlice(obj.Object, "spec", "parentRefs")
+	for _, parentRef := range parentRefs {
+		if ref, ok := parentRef.(map[string]interface{}); ok {
+			p := g.ObjectReference(ref, GatewayGroupVersion.Group, "Gateway", obj.GetNamespace())
+			if p != nil {
+				g.graph.Relationship(n, p.Kind, p)
+			}
+		}
+	}
+
+	rules, _, _ := unstructured.NestedSlice(obj.Object, "spec", "rules")
+	for _, rule := range rules {
+		r, ok := rule.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		backendRefs, _, _ := unstructured.NestedSlice(r, "backendRefs")
+		for _, backendRef := range backendRefs {
+			if ref, ok := backendRef.(map[string]interface{}); ok {
+				b := g.ObjectReference(ref, "", "Service", obj.GetNamespace())
+				if b != nil {
+					g.graph.Relationship(n, b.Kind, b)
+				}
+			}
+		}
+	}
+
+	return n, nil
+}
+
+// ObjectReference adds a gateway api object reference to the Graph.
+// The optional group, kind and namespace fields fall back to the given defaults.
+func (g *GatewayV1Graph) ObjectReference(ref map[string]interface{}, group string, kind string, namespace string) *Node {
+	name, _, _ := unstructured.NestedString(ref, "name")
+	if len(name) == 0 {
+		return nil
+	}
+
+	if value, ok, _ := unstructured.NestedString(ref, "group"); ok {
+		group = value
+	}
+	if value, _, _ := unstructured.NestedString(ref, "kind"); len(value) != 0 {
+		kind = value
+	}
+	if value, _, _ := unstructured.NestedString(ref, "namespace"); len(value) != 0 {
+		namespace = value
+	}
+
+	// References do not carry a version, which is only used for display.
+	return g.graph.Reference(schema.GroupVersionKind{Group: group, Version: "v1", Kind: kind}, namespace, name)
+}
@@ -16,31 +16,84 @@ package graph
 
 import (
 	"bytes"
+	"context"
 	"crypto/md5"
 	"embed"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
+	"path"
 	"regexp"
+	"sort"
 	"strings"
 	"text/template"
+	"time"
 
+	authorizationv1 "k8s.io/api/authorization/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/duration"
 	"k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/jsonpath"
 	"sigs.k8s.io/yaml"
 )
 
 const (
 	// DefaultNodeNameLimit represents the default limit to truncate the node name to N characters.
 	DefaultNodeNameLimit int = 12
+	// DefaultChunkSize represents the default number of objects to list at once.
+	DefaultChunkSize int64 = 500
+	// DefaultLayout represents the default graphviz layout engine.
+	DefaultLayout string = "sfdp"
+	// DefaultRankDir represents the default graphviz direction of the graph layout.
+	DefaultRankDir string = "TB"
 )
 
+// ContextAnnotation is the annotation of merged nodes with the name of the kubeconfig context.
+const ContextAnnotation string = "kubectl-graph/context"
+
+const (
+	// RelationshipOwns represents the label of a relationship from an owner to the owned node.
+	RelationshipOwns string = "owns"
+	// RelationshipControls represents the label of a relationship from the controlling owner to the owned node.
+	RelationshipControls string = "controls"
+	// RelationshipSelects represents the label of a relationship from a selector to the selected node.
+	RelationshipSelects string = "selects"
+	// RelationshipReferences represents the label of a relationship to a referenced node.
+	RelationshipReferences string = "references"
+)
+
+// relationshipDescriptions contains the descriptions of the relationship labels used in the legend.
+var relationshipDescriptions = map[string]string{
+	RelationshipOwns:       "owner of the target",
+	RelationshipControls:   "controlling owner of the target by an owner reference",
+	RelationshipSelects:    "label selector matching the target",
+	RelationshipReferences: "target referenced by name",
+	"applies-to":           "network policy applying to the target pod",
+	"egress-to":            "traffic allowed to the target",
+	"ingress-from":         "traffic allowed from the source",
+	"launched":             "node claim which launched the target node",
+	"provisions":           "node pool which provisioned the target node",
+	"runs":                 "node running the target pod",
+}
+
+// referrerKinds contains the kinds of workloads and pods, which are kept as referrers of the listed objects.
+var referrerKinds = map[schema.GroupKind]bool{
+	{Group: "", Kind: "Pod"}:             true,
+	{Group: "apps", Kind: "DaemonSet"}:   true,
+	{Group: "apps", Kind: "Deployment"}:  true,
+	{Group: "apps", Kind: "ReplicaSet"}:  true,
+	{Group: "apps", Kind: "StatefulSet"}: true,
+	{Group: "batch", Kind: "CronJob"}:    true,
+	{Group: "batch", Kind: "Job"}:        true,
+}
+
 var (
 	//go:embed templates/*.tmpl
 	templateFiles embed.FS
@@ -71,6 +124,27 @@ func init() {
 			hash := md5.Sum([]byte(s))
 			return fmt.Sprintf("#%x", hash[:3])
 		},
+		"mermaid": func(s string) string {
+			return strings.NewReplacer(`"`, "#quot;", "\n", " ").Replace(s)
+		},
+		"csv": func(fields ...string) string {
+			b := &bytes.Buffer{}
+			w := csv.NewWriter(b)
+			if err := w.Write(fields); err != nil {
+				return err.Error()
+			}
+			w.Flush()
+			return strings.TrimSuffix(b.String(), "\n")
+		},
+		"cypher": func(s string) string {
+			return "`" + strings.ReplaceAll(s, "`", "``") + "`"
+		},
+		"gremlin": func(s string) string {
+			return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\n", `\n`, "\r", `\r`).Replace(s) + "'"
+		},
+		"indent": func(depth int) string {
+			return strings.Repeat("  ", depth)
+		},
 		"truncate": func(s string, max int) string {
 			if max < 3 {
 				max = 3
@@ -86,35 +160,119 @@ func init() {
 }
 
 // Graph stores nodes and relationships between them.
+// Nodes are keyed by UID and relationships are keyed by the UID of their target node,
+// so all relationships to a node are found with Relationships[node.UID].
+// A Graph is created by NewGraph and populated by Build, which can be used without the kubectl plugin.
 type Graph struct {
 	Nodes         map[types.UID]*Node
 	Relationships map[types.UID][]*Relationship
 	Options       *Options
 
-	clientset *kubernetes.Clientset
+	ctx        context.Context
+	clientset  *kubernetes.Clientset
+	allowed    map[authorizationv1.ResourceAttributes]bool
+	namespaced map[schema.GroupVersionKind]bool
+	references map[types.UID]reference
+	roots      map[types.UID]bool
 
-	coreV1       *CoreV1Graph
-	networkingV1 *NetworkingV1Graph
-	routeV1      *RouteV1Graph
+	admissionRegistrationV1 *AdmissionRegistrationV1Graph
+	appsV1                  *AppsV1Graph
+	autoscalingV2           *AutoscalingV2Graph
+	batchV1                 *BatchV1Graph
+	certManagerV1           *CertManagerV1Graph
+	clusterAPIV1            *ClusterAPIV1Graph
+	coreV1                  *CoreV1Graph
+	crossplaneV1            *CrossplaneV1Graph
+	externalSecretsV1       *ExternalSecretsV1Graph
+	fluxV1                  *FluxV1Graph
+	gatewayV1               *GatewayV1Graph
+	helmV3                  *HelmV3Graph
+	istioNetworkingV1       *IstioNetworkingV1Graph
+	karpenterV1             *KarpenterV1Graph
+	knativeServingV1        *KnativeServingV1Graph
+	kubeVirtV1              *KubeVirtV1Graph
+	kueueV1                 *KueueV1Graph
+	monitoringV1            *MonitoringV1Graph
+	networkingV1            *NetworkingV1Graph
+	policyV1                *PolicyV1Graph
+	policyReportV1          *PolicyReportV1Graph
+	rbacV1                  *RbacV1Graph
+	rookCephV1              *RookCephV1Graph
+	routeV1                 *RouteV1Graph
+	storageV1               *StorageV1Graph
+	tektonV1                *TektonV1Graph
+	veleroV1                *VeleroV1Graph
 }
 
 // Node represents a node in the graph.
+// It holds the type and the object metadata of a resource, while the full object is only used internally.
 type Node struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
+
+	object map[string]interface{}
+	usage  v1.ResourceList
+}
+
+// reference identifies a node by kind, namespace and name if the UID is unknown.
+type reference struct {
+	schema.GroupKind
+	Namespace string
+	Name      string
 }
 
-// Relationship represents a relationship between nodes in the graph.
+// Relationship represents a labeled relationship from the node with UID From to the node with UID To.
 type Relationship struct {
-	From  types.UID
-	Label string
-	To    types.UID
-	Attr  map[string]string
+	From       types.UID
+	Label      string
+	To         types.UID
+	Attr       map[string]string
+	Undirected bool
 }
 
 // Options represents attributes to configure the graph.
 type Options struct {
-	NodeNameLimit int
+	ChunkSize       int64
+	EdgeAnnotations []string
+	Fields          []string
+	Layout          string
+	Legend          bool
+	NodeNameLimit   int
+	NodePods        bool
+	RankDir         string
+	ShowAge         bool
+	StatusColors    map[string]string
+
+	// Processed is called after each object passed to Build is processed, if set.
+	Processed func()
+}
+
+// DefaultOptions returns the default options of a Graph.
+func DefaultOptions() *Options {
+	return &Options{
+		ChunkSize:     DefaultChunkSize,
+		Layout:        DefaultLayout,
+		NodeNameLimit: DefaultNodeNameLimit,
+		RankDir:       DefaultRankDir,
+		StatusColors:  DefaultStatusColors(),
+	}
+}
+
+// DefaultStatusColors returns the default mapping of node states to graphviz fill colors.
+func DefaultStatusColors() map[string]string {
+	colors := make(map[string]string)
+
+	for _, state := range []string{"Active", "Available", "Bound", "Healthy", "Ready", "Running", "Succeeded"} {
+		colors[state] = "#34a8535e"
+	}
+	for _, state := range []string{"ContainerCreating", "Pending", "Progressing"} {
+		colors[state] = "#fbbc055e"
+	}
+	for _, state := range []string{"CrashLoopBackOff", "Degraded", "ErrImagePull", "Error", "Failed", "ImagePullBackOff", "Lost", "NotReady", "Unavailable"} {
+		colors[state] = "#ea43355e"
+	}
+
+	return colors
 }
 
 // ToUID converts all params to MD5 and returns this as types.UID.
@@ -160,29 +318,75 @@ func FromUnstructured(unstr *unstructured.Unstructured, obj runtime.Object) erro
 	return nil
 }
 
-// NewGraph returns a new initialized a Graph.
-func NewGraph(clientset *kubernetes.Clientset, objs []*unstructured.Unstructured, processed func()) (*Graph, error) {
+// NewGraph returns a new initialized and empty Graph with default options.
+func NewGraph(clientset *kubernetes.Clientset) *Graph {
 	g := &Graph{
+		ctx:           context.Background(),
 		clientset:     clientset,
+		allowed:       make(map[authorizationv1.ResourceAttributes]bool),
+		namespaced:    make(map[schema.GroupVersionKind]bool),
+		references:    make(map[types.UID]reference),
+		roots:         make(map[types.UID]bool),
 		Nodes:         make(map[types.UID]*Node),
 		Relationships: make(map[types.UID][]*Relationship),
-		Options: &Options{
-			NodeNameLimit: DefaultNodeNameLimit,
-		},
+		Options:       DefaultOptions(),
 	}
 
+	g.admissionRegistrationV1 = NewAdmissionRegistrationV1Graph(g)
+	g.appsV1 = NewAppsV1Graph(g)
+	g.autoscalingV2 = NewAutoscalingV2Graph(g)
+	g.batchV1 = NewBatchV1Graph(g)
+	g.certManagerV1 = NewCertManagerV1Graph(g)
+	g.clusterAPIV1 = NewClusterAPIV1Graph(g)
 	g.coreV1 = NewCoreV1Graph(g)
+	g.crossplaneV1 = NewCrossplaneV1Graph(g)
+	g.externalSecretsV1 = NewExternalSecretsV1Graph(g)
+	g.fluxV1 = NewFluxV1Graph(g)
+	g.gatewayV1 = NewGatewayV1Graph(g)
+	g.helmV3 = NewHelmV3Graph(g)
+	g.istioNetworkingV1 = NewIstioNetworkingV1Graph(g)
+	g.karpenterV1 = NewKarpenterV1Graph(g)
+	g.knativeServingV1 = NewKnativeServingV1Graph(g)
+	g.kubeVirtV1 = NewKubeVirtV1Graph(g)
+	g.kueueV1 = NewKueueV1Graph(g)
+	g.monitoringV1 = NewMonitoringV1Graph(g)
 	g.networkingV1 = NewNetworkingV1Graph(g)
+	g.policyV1 = NewPolicyV1Graph(g)
+	g.policyReportV1 = NewPolicyReportV1Graph(g)
+	g.rbacV1 = NewRbacV1Graph(g)
+	g.rookCephV1 = NewRookCephV1Graph(g)
 	g.routeV1 = NewRouteV1Graph(g)
+	g.storageV1 = NewStorageV1Graph(g)
+	g.tektonV1 = NewTektonV1Graph(g)
+	g.veleroV1 = NewVeleroV1Graph(g)
+
+	return g
+}
+
+// Build adds all objects and their relationships to the Graph and returns the populated Graph.
+// Objects which fail are reported in the returned error, while all other objects are still added.
+// The context is used for all requests to the API server and Build stops early with its error if it is cancelled.
+func (g *Graph) Build(ctx context.Context, objs []*unstructured.Unstructured) (*Graph, error) {
+	g.ctx = ctx
+	defer func() { g.ctx = context.Background() }()
 
 	errs := []error{}
 
 	for _, obj := range objs {
-		_, err := g.Unstructured(obj)
+		if err := ctx.Err(); err != nil {
+			return g, err
+		}
+
+		n, err := g.Unstructured(obj)
 		if err != nil {
 			errs = append(errs, err)
 		}
-		processed()
+		if n != nil {
+			g.roots[n.UID] = true
+		}
+		if g.Options.Processed != nil {
+			g.Options.Processed()
+		}
 	}
 
 	err := g.Finalize()
@@ -193,21 +397,126 @@ func NewGraph(clientset *kubernetes.Clientset, objs []*unstructured.Unstructured
 	return g, errors.NewAggregate(errs)
 }
 
+// Referrers adds all objects and their relationships to the Graph like Build, but the objects are not listed.
+// Afterwards only the listed objects and the workloads and pods which reference them, directly or through other workloads, are kept.
+// Other referrers, like the namespace, services or endpoints, are dropped.
+func (g *Graph) Referrers(ctx context.Context, objs []*unstructured.Unstructured) (*Graph, error) {
+	g.ctx = ctx
+	defer func() { g.ctx = context.Background() }()
+
+	errs := []error{}
+
+	for _, obj := range objs {
+		if err := ctx.Err(); err != nil {
+			return g, err
+		}
+
+		if _, err := g.Unstructured(obj); err != nil {
+			errs = append(errs, err)
+		}
+		if g.Options.Processed != nil {
+			g.Options.Processed()
+		}
+	}
+
+	if err := g.Finalize(); err != nil {
+		errs = append(errs, err)
+	}
+
+	visited := make(map[types.UID]bool)
+	queue := []types.UID{}
+	for uid := range g.roots {
+		if _, ok := g.Nodes[uid]; ok {
+			visited[uid] = true
+			queue = append(queue, uid)
+		}
+	}
+
+	for len(queue) != 0 {
+		uid := queue[0]
+		queue = queue[1:]
+		// The owners are related to the owned nodes, so the workloads controlling a referrer are followed as well.
+		for _, r := range g.Relationships[uid] {
+			from, ok := g.Nodes[r.From]
+			if !ok || !referrerKinds[from.GroupVersionKind().GroupKind()] {
+				continue
+			}
+			if !visited[r.From] {
+				visited[r.From] = true
+				queue = append(queue, r.From)
+			}
+		}
+	}
+
+	g.retain(visited)
+
+	return g, errors.NewAggregate(errs)
+}
+
 // Unstructured adds an unstructured node to the Graph.
 func (g *Graph) Unstructured(unstr *unstructured.Unstructured) (*Node, error) {
 	switch unstr.GetAPIVersion() {
+	case "admissionregistration.k8s.io/v1":
+		return g.AdmissionRegistrationV1().Unstructured(unstr)
+	case "apps/v1":
+		return g.AppsV1().Unstructured(unstr)
+	case "autoscaling/v1", "autoscaling/v2":
+		return g.AutoscalingV2().Unstructured(unstr)
+	case "batch/v1":
+		return g.BatchV1().Unstructured(unstr)
+	case "cert-manager.io/v1":
+		return g.CertManagerV1().Unstructured(unstr)
+	case "cluster.x-k8s.io/v1beta1", "cluster.x-k8s.io/v1beta2":
+		return g.ClusterAPIV1().Unstructured(unstr)
 	case "v1":
 		return g.CoreV1().Unstructured(unstr)
+	case "external-secrets.io/v1", "external-secrets.io/v1beta1":
+		return g.ExternalSecretsV1().Unstructured(unstr)
+	case "gateway.networking.k8s.io/v1", "gateway.networking.k8s.io/v1beta1":
+		return g.GatewayV1().Unstructured(unstr)
+	case "networking.istio.io/v1", "networking.istio.io/v1beta1", "networking.istio.io/v1alpha3":
+		return g.IstioNetworkingV1().Unstructured(unstr)
+	case "karpenter.sh/v1", "karpenter.sh/v1beta1":
+		return g.KarpenterV1().Unstructured(unstr)
+	case "serving.knative.dev/v1":
+		return g.KnativeServingV1().Unstructured(unstr)
+	case "kubevirt.io/v1", "cdi.kubevirt.io/v1beta1":
+		return g.KubeVirtV1().Unstructured(unstr)
+	case "kueue.x-k8s.io/v1beta1":
+		return g.KueueV1().Unstructured(unstr)
+	case "kustomize.toolkit.fluxcd.io/v1", "kustomize.toolkit.fluxcd.io/v1beta2",
+		"helm.toolkit.fluxcd.io/v2", "helm.toolkit.fluxcd.io/v2beta1", "helm.toolkit.fluxcd.io/v2beta2":
+		return g.FluxV1().Unstructured(unstr)
+	case "monitoring.coreos.com/v1":
+		return g.MonitoringV1().Unstructured(unstr)
 	case "networking.k8s.io/v1":
 		return g.NetworkingV1().Unstructured(unstr)
+	case "policy/v1":
+		return g.PolicyV1().Unstructured(unstr)
+	case "wgpolicyk8s.io/v1alpha2", "wgpolicyk8s.io/v1beta1":
+		return g.PolicyReportV1().Unstructured(unstr)
+	case "rbac.authorization.k8s.io/v1":
+		return g.RbacV1().Unstructured(unstr)
+	case "ceph.rook.io/v1":
+		return g.RookCephV1().Unstructured(unstr)
 	case "route.openshift.io/v1":
 		return g.RouteV1().Unstructured(unstr)
+	case "storage.k8s.io/v1":
+		return g.StorageV1().Unstructured(unstr)
+	case "tekton.dev/v1", "tekton.dev/v1beta1":
+		return g.TektonV1().Unstructured(unstr)
+	case "velero.io/v1":
+		return g.VeleroV1().Unstructured(unstr)
 	default:
+		if IsCrossplaneResource(unstr) {
+			return g.CrossplaneV1().Unstructured(unstr)
+		}
 		return g.Node(unstr.GroupVersionKind(), unstr), nil
 	}
 }
 
-// Node adds a node and the owner references to the Graph.
+// Node adds a node and the owner references to the Graph or updates the existing node with the same UID.
+// Nodes managed by flux are related to their Kustomization or HelmRelease.
 func (g *Graph) Node(gvk schema.GroupVersionKind, obj metav1.Object) *Node {
 	apiVersion, kind := gvk.ToAPIVersionAndKind()
 	node := &Node{
@@ -226,13 +535,28 @@ func (g *Graph) Node(gvk schema.GroupVersionKind, obj metav1.Object) *Node {
 		},
 	}
 
+	if o, ok := obj.(runtime.Object); ok {
+		if object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(o); err == nil {
+			node.object = object
+		}
+	}
+
+	// An object reached through several code paths is deduplicated by its UID,
+	// so the existing node is updated and returned instead of adding a duplicate.
 	if n, ok := g.Nodes[obj.GetUID()]; ok {
-		if len(n.GetAnnotations()) != 0 {
-			node.SetAnnotations(n.GetAnnotations())
+		n.TypeMeta = node.TypeMeta
+		n.SetNamespace(node.GetNamespace())
+		n.SetName(node.GetName())
+		if len(n.GetAnnotations()) == 0 {
+			n.SetAnnotations(node.GetAnnotations())
+		}
+		if len(n.GetLabels()) == 0 {
+			n.SetLabels(node.GetLabels())
 		}
-		if len(n.GetLabels()) != 0 {
-			node.SetLabels(n.GetLabels())
+		if node.object != nil {
+			n.object = node.object
 		}
+		node = n
 	}
 
 	g.Nodes[obj.GetUID()] = node
@@ -246,14 +570,458 @@ func (g *Graph) Node(gvk schema.GroupVersionKind, obj metav1.Object) *Node {
 				Namespace: obj.GetNamespace(),
 			},
 		)
-		g.Relationship(owner, kind, node)
+		// An object has at most one controller, the other owners only block its garbage collection.
+		if ownerRef.Controller != nil && *ownerRef.Controller {
+			g.Relationship(owner, RelationshipControls, node)
+		} else {
+			g.Relationship(owner, RelationshipOwns, node).Attribute("style", "dashed")
+		}
 	}
 
+	g.FluxV1().Managed(node)
+
 	return node
 }
 
+// Allowed returns true if the current user is allowed to perform the action on the resource.
+// The result of each SelfSubjectAccessReview is cached to reduce the number of requests.
+func (g *Graph) Allowed(attr authorizationv1.ResourceAttributes) (bool, error) {
+	if allowed, ok := g.allowed[attr]; ok {
+		return allowed, nil
+	}
+
+	review := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &attr,
+		},
+	}
+
+	options := metav1.CreateOptions{}
+	review, err := g.clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(g.ctx, review, options)
+	if err != nil {
+		return false, err
+	}
+	g.allowed[attr] = review.Status.Allowed
+
+	return review.Status.Allowed, nil
+}
+
+// Namespaced returns true if the resource of the kind is namespaced, which is discovered once for each group version.
+// Kinds which cannot be discovered, like those of custom resources which are not installed, are assumed to be namespaced.
+func (g *Graph) Namespaced(gvk schema.GroupVersionKind) bool {
+	if namespaced, ok := g.namespaced[gvk]; ok {
+		return namespaced
+	}
+
+	gv := gvk.GroupVersion()
+	g.namespaced[gvk] = true
+
+	list, err := g.clientset.Discovery().ServerResourcesForGroupVersion(gv.String())
+	if err != nil {
+		return true
+	}
+
+	for _, resource := range list.APIResources {
+		if !strings.Contains(resource.Name, "/") {
+			g.namespaced[gv.WithKind(resource.Kind)] = resource.Namespaced
+		}
+	}
+
+	return g.namespaced[gvk]
+}
+
+// List lists all resources of the group version within a namespace in chunks of the configured size.
+// An empty list is returned if the current user is not allowed to list the resources.
+func (g *Graph) List(gv schema.GroupVersion, namespace string, resource string, options metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	p := path.Join("/apis", gv.Group, gv.Version)
+	if len(namespace) != 0 {
+		p = path.Join(p, "namespaces", namespace)
+	}
+	p = path.Join(p, resource)
+
+	attr := authorizationv1.ResourceAttributes{Namespace: namespace, Verb: "list", Group: gv.Group, Resource: resource}
+	items, err := listChunks(g, attr, options, func(ctx context.Context, options metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+		request := g.clientset.Discovery().RESTClient().Get().AbsPath(p).
+			Param("labelSelector", options.LabelSelector).
+			Param("limit", fmt.Sprint(options.Limit))
+		if len(options.Continue) != 0 {
+			request = request.Param("continue", options.Continue)
+		}
+
+		body, err := request.Do(ctx).Raw()
+		if err != nil {
+			return nil, err
+		}
+
+		chunk := &unstructured.UnstructuredList{}
+		if err := chunk.UnmarshalJSON(body); err != nil {
+			return nil, fmt.Errorf("failed to decode: %v", err)
+		}
+
+		return chunk, nil
+	}, func(chunk *unstructured.UnstructuredList) []unstructured.Unstructured { return chunk.Items })
+	if err != nil {
+		return nil, err
+	}
+
+	return &unstructured.UnstructuredList{Items: items}, nil
+}
+
+// listChunks lists all items of a resource with the list function in chunks of the configured size.
+// The items of each chunk are returned by the items function, and the next chunk is requested with its continue token.
+// No items are returned if the current user is not allowed to list the resource.
+func listChunks[L interface{ GetContinue() string }, T any](g *Graph, attr authorizationv1.ResourceAttributes, options metav1.ListOptions, list func(context.Context, metav1.ListOptions) (L, error), items func(L) []T) ([]T, error) {
+	options.Limit = g.Options.ChunkSize
+
+	allowed, err := g.Allowed(attr)
+	if err != nil || !allowed {
+		return nil, err
+	}
+
+	all := []T{}
+	for {
+		chunk, err := list(g.ctx, options)
+		if err != nil {
+			if len(attr.Namespace) == 0 {
+				return nil, fmt.Errorf("failed to list %s: %v", attr.Resource, err)
+			}
+			return nil, fmt.Errorf("failed to list %s in namespace %q: %v", attr.Resource, attr.Namespace, err)
+		}
+		all = append(all, items(chunk)...)
+
+		if len(chunk.GetContinue()) == 0 {
+			return all, nil
+		}
+		options.Continue = chunk.GetContinue()
+	}
+}
+
+// Reference adds a node to the Graph which is only known by kind, namespace and name.
+// When the Graph is finalized, the node is replaced by the matching node with a known UID
+// or remains as a placeholder if the referenced resource is not part of the Graph.
+func (g *Graph) Reference(gvk schema.GroupVersionKind, namespace string, name string) *Node {
+	ref := reference{GroupKind: gvk.GroupKind(), Namespace: namespace, Name: name}
+	uid := ToUID(ref.Group, ref.Kind, ref.Namespace, ref.Name)
+	g.references[uid] = ref
+
+	return g.Node(gvk, &metav1.ObjectMeta{UID: uid, Namespace: namespace, Name: name})
+}
+
+// AnnotationReferences relates all nodes to the objects referenced by the values of the configured annotations.
+// A value is a comma separated list of names or KIND/NAME, where a name without kind references an object of the same kind.
+// The referenced objects are matched by kind and name within the namespace of the node or cluster-wide
+// and are added as placeholder if they are not part of the Graph.
+// The kind is matched case-insensitive, so the placeholder has the group and spelling of a known node of the kind.
+func (g *Graph) AnnotationReferences() {
+	if len(g.Options.EdgeAnnotations) == 0 {
+		return
+	}
+
+	nodes := g.NodeList()
+	targets := make(map[reference]*Node)
+	kinds := make(map[string]schema.GroupVersionKind)
+	for _, node := range nodes {
+		ref := reference{GroupKind: schema.GroupKind{Kind: strings.ToLower(node.Kind)}, Namespace: node.Namespace, Name: node.Name}
+		if _, ok := targets[ref]; !ok {
+			targets[ref] = node
+		}
+		if _, ok := kinds[ref.Kind]; !ok {
+			kinds[ref.Kind] = node.GroupVersionKind()
+		}
+	}
+
+	for _, node := range nodes {
+		for _, key := range g.Options.EdgeAnnotations {
+			value, ok := node.Annotations[key]
+			if !ok {
+				continue
+			}
+
+			for _, item := range strings.Split(value, ",") {
+				kind, name, found := strings.Cut(strings.TrimSpace(item), "/")
+				if !found {
+					kind, name = node.Kind, kind
+				}
+				if len(kind) == 0 || len(name) == 0 {
+					continue
+				}
+
+				groupKind := schema.GroupKind{Kind: strings.ToLower(kind)}
+				target, ok := targets[reference{GroupKind: groupKind, Namespace: node.Namespace, Name: name}]
+				if !ok {
+					target, ok = targets[reference{GroupKind: groupKind, Name: name}]
+				}
+				if !ok {
+					gvk, known := kinds[groupKind.Kind]
+					if !known {
+						gvk = schema.GroupVersionKind{Kind: kind}
+					}
+					target = g.Reference(gvk, node.Namespace, name)
+				}
+
+				g.Relationship(node, key, target)
+			}
+		}
+	}
+}
+
+// ResolveReferences replaces all referenced nodes by matching nodes with a known UID.
+func (g *Graph) ResolveReferences() {
+	// The nodes are iterated in order, so a reference matching multiple nodes is always replaced by the same node.
+	uids := make(map[reference]types.UID)
+	for _, node := range g.NodeList() {
+		if _, ok := g.references[node.UID]; ok {
+			continue
+		}
+		gv, _ := schema.ParseGroupVersion(node.APIVersion)
+		uids[reference{GroupKind: gv.WithKind(node.Kind).GroupKind(), Namespace: node.Namespace, Name: node.Name}] = node.UID
+	}
+
+	replaced := make(map[types.UID]types.UID)
+	for from, ref := range g.references {
+		if to, ok := uids[ref]; ok {
+			replaced[from] = to
+		}
+	}
+
+	if len(replaced) == 0 {
+		return
+	}
+
+	relationships := g.RelationshipList()
+	g.Relationships = make(map[types.UID][]*Relationship)
+
+	for _, r := range relationships {
+		if uid, ok := replaced[r.From]; ok {
+			r.From = uid
+		}
+		if uid, ok := replaced[r.To]; ok {
+			r.To = uid
+		}
+		if g.lookup(r.From, r.Label, r.To) == nil {
+			g.Relationships[r.To] = append(g.Relationships[r.To], r)
+		}
+	}
+
+	for uid := range replaced {
+		delete(g.Nodes, uid)
+		delete(g.references, uid)
+	}
+}
+
+// State returns the most significant state of the node like Running, Pending or CrashLoopBackOff,
+// read from the container statuses, the health, the phase or the Ready and Available conditions.
+// An empty string is returned if the node has no recognizable status.
+func (n *Node) State() string {
+	for _, field := range []string{"initContainerStatuses", "containerStatuses"} {
+		statuses, _, _ := unstructured.NestedSlice(n.object, "status", field)
+		for _, status := range statuses {
+			if s, ok := status.(map[string]interface{}); ok {
+				if reason, _, _ := unstructured.NestedString(s, "state", "waiting", "reason"); len(reason) != 0 {
+					return reason
+				}
+			}
+		}
+	}
+
+	if health, _, _ := unstructured.NestedString(n.object, "status", "health", "status"); len(health) != 0 {
+		return health
+	}
+
+	if phase, _, _ := unstructured.NestedString(n.object, "status", "phase"); len(phase) != 0 {
+		return phase
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(n.object, "status", "conditions")
+	for _, condition := range conditions {
+		c, ok := condition.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch c["type"] {
+		case "Ready":
+			if c["status"] == "True" {
+				return "Ready"
+			}
+			return "NotReady"
+		case "Available":
+			if c["status"] == "True" {
+				return "Available"
+			}
+			return "Unavailable"
+		}
+	}
+
+	return ""
+}
+
+// Summary returns a short multi-line summary of the status of the node with the phase,
+// the ready replicas and the status of all conditions. An empty string is returned if the node has no status.
+func (n *Node) Summary() string {
+	lines := []string{}
+
+	if state := n.State(); len(state) != 0 {
+		lines = append(lines, fmt.Sprintf("state: %s", state))
+	}
+
+	if replicas, ok, _ := unstructured.NestedInt64(n.object, "spec", "replicas"); ok {
+		ready, _, _ := unstructured.NestedInt64(n.object, "status", "readyReplicas")
+		lines = append(lines, fmt.Sprintf("ready: %d/%d", ready, replicas))
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(n.object, "status", "conditions")
+	for _, condition := range conditions {
+		c, ok := condition.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%v: %v", c["type"], c["status"]))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Created returns the creation timestamp of the node or the zero time if it is unknown.
+func (n *Node) Created() time.Time {
+	timestamp, _, _ := unstructured.NestedString(n.object, "metadata", "creationTimestamp")
+
+	created, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return created
+}
+
+// Age returns the human readable age of the node like 3d or 5h or an empty string if it is unknown.
+func (n *Node) Age() string {
+	created := n.Created()
+	if created.IsZero() {
+		return ""
+	}
+
+	return duration.HumanDuration(time.Since(created))
+}
+
+// Label returns the value of the label of the node or an empty string if it is not set.
+func (n *Node) Label(key string) string {
+	if labels, _, _ := unstructured.NestedStringMap(n.object, "metadata", "labels"); len(labels) != 0 {
+		return labels[key]
+	}
+
+	return n.GetLabels()[key]
+}
+
+// Annotation returns the value of the annotation of the node or an empty string if it is not set.
+// Unlike the annotations of the node, this includes values in JSON which are read from the stored object.
+func (n *Node) Annotation(key string) string {
+	if annotations, _, _ := unstructured.NestedStringMap(n.object, "metadata", "annotations"); len(annotations) != 0 {
+		return annotations[key]
+	}
+
+	return n.GetAnnotations()[key]
+}
+
+// Status returns the status of the stored object of the node or nil if it has none.
+func (n *Node) Status() map[string]interface{} {
+	status, _, _ := unstructured.NestedMap(n.object, "status")
+
+	return status
+}
+
+// StatusColor returns the configured color for the state of a node or an empty string.
+func (g *Graph) StatusColor(n *Node) string {
+	state := n.State()
+	if len(state) == 0 {
+		return ""
+	}
+
+	return g.Options.StatusColors[state]
+}
+
+// LegendEntry represents a single state or relationship label explained by the legend.
+type LegendEntry struct {
+	Name        string
+	Color       string
+	Description string
+}
+
+// LegendStates returns the states of all nodes with a configured color sorted by name.
+func (g *Graph) LegendStates() []LegendEntry {
+	states := make(map[string]bool)
+	for _, node := range g.Nodes {
+		states[node.State()] = true
+	}
+
+	entries := []LegendEntry{}
+	for state := range states {
+		if color, ok := g.Options.StatusColors[state]; ok {
+			entries = append(entries, LegendEntry{Name: state, Color: color})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+
+	return entries
+}
+
+// LegendRelationships returns the labels of all relationships with a known description sorted by name.
+func (g *Graph) LegendRelationships() []LegendEntry {
+	labels := make(map[string]bool)
+	for _, r := range g.RelationshipList() {
+		labels[r.Label] = true
+	}
+
+	entries := []LegendEntry{}
+	for label := range labels {
+		if description, ok := relationshipDescriptions[label]; ok {
+			entries = append(entries, LegendEntry{Name: label, Description: description})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+
+	return entries
+}
+
+// NodeFields returns the values of the configured fields of a node, which are JSONPath expressions like status.phase.
+// Fields which are invalid or missing in the stored object of the node are omitted.
+func (g *Graph) NodeFields(n *Node) map[string]interface{} {
+	fields := make(map[string]interface{})
+
+	for _, field := range g.Options.Fields {
+		expression := strings.TrimSuffix(strings.TrimPrefix(field, "{"), "}")
+		j := jsonpath.New(field)
+		if err := j.Parse("{." + strings.TrimPrefix(expression, ".") + "}"); err != nil {
+			continue
+		}
+
+		results, err := j.FindResults(n.object)
+		if err != nil || len(results) == 0 || len(results[0]) == 0 {
+			continue
+		}
+
+		values := []interface{}{}
+		for _, result := range results[0] {
+			values = append(values, result.Interface())
+		}
+		if len(values) == 1 {
+			fields[field] = values[0]
+			continue
+		}
+		fields[field] = values
+	}
+
+	return fields
+}
+
 // Finalize adds missing relationships to the Graph.
 func (g *Graph) Finalize() error {
+	g.AnnotationReferences()
+	g.ResolveReferences()
+
 	for _, node := range g.Nodes {
 		if node.Kind == "Cluster" || node.Kind == "Namespace" {
 			continue
@@ -284,7 +1052,8 @@ func (g *Graph) Finalize() error {
 	return nil
 }
 
-// NodeList returns a list of all nodes.
+// NodeList returns a list of all nodes sorted by UID.
+// All output formats iterate the nodes in this order, so the same objects always result in the same output.
 func (g *Graph) NodeList() []*Node {
 	nodes := []*Node{}
 
@@ -292,17 +1061,144 @@ func (g *Graph) NodeList() []*Node {
 		nodes = append(nodes, node)
 	}
 
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].UID < nodes[j].UID
+	})
+
 	return nodes
 }
 
-// Relationship creates a new relationship between two nodes.
-func (g *Graph) Relationship(from *Node, label string, to *Node) *Relationship {
-	if rs, ok := g.Relationships[to.GetUID()]; ok {
-		for _, r := range rs {
-			if r.From == from.GetUID() {
-				return r
+// lookup returns the existing relationship between two nodes with the same label or nil.
+func (g *Graph) lookup(from types.UID, label string, to types.UID) *Relationship {
+	for _, r := range g.Relationships[to] {
+		if r.From == from && r.Label == label {
+			return r
+		}
+	}
+
+	return nil
+}
+
+// Merge adds all nodes and relationships of another Graph, e.g. built from another cluster, to the Graph.
+// The UIDs are prefixed by the name of the context to prevent collisions of synthetic nodes between clusters,
+// and each node is annotated with its context.
+func (g *Graph) Merge(other *Graph, context string) {
+	uid := func(u types.UID) types.UID {
+		return ToUID(context, u)
+	}
+
+	for _, n := range other.NodeList() {
+		node := *n
+		node.UID = uid(n.UID)
+		node.Annotations = map[string]string{ContextAnnotation: context}
+		for key, value := range n.Annotations {
+			node.Annotations[key] = value
+		}
+		g.Nodes[node.UID] = &node
+
+		if other.roots[n.UID] {
+			g.roots[node.UID] = true
+		}
+	}
+
+	for _, r := range other.RelationshipList() {
+		relationship := *r
+		relationship.From = uid(r.From)
+		relationship.To = uid(r.To)
+		g.Relationships[relationship.To] = append(g.Relationships[relationship.To], &relationship)
+	}
+}
+
+// NodeIDs returns a numeric ID for each node UID, which is the index of the node in NodeList.
+func (g *Graph) NodeIDs() map[types.UID]int {
+	ids := make(map[types.UID]int, len(g.Nodes))
+	for id, node := range g.NodeList() {
+		ids[node.UID] = id
+	}
+
+	return ids
+}
+
+// KindGroups returns a numeric group for each kind of the nodes, numbered in alphabetical order of the kinds.
+func (g *Graph) KindGroups() map[string]int {
+	kinds := []string{}
+	groups := make(map[string]int)
+	for _, node := range g.Nodes {
+		if _, ok := groups[node.Kind]; !ok {
+			groups[node.Kind] = 0
+			kinds = append(kinds, node.Kind)
+		}
+	}
+
+	sort.Strings(kinds)
+	for group, kind := range kinds {
+		groups[kind] = group
+	}
+
+	return groups
+}
+
+// NodeListByNamespace returns all nodes grouped by namespace, where cluster-scoped nodes have an empty namespace.
+func (g *Graph) NodeListByNamespace() map[string][]*Node {
+	nodes := make(map[string][]*Node)
+
+	for _, node := range g.NodeList() {
+		nodes[node.Namespace] = append(nodes[node.Namespace], node)
+	}
+
+	return nodes
+}
+
+// Split partitions the Graph into one Graph per namespace, where cluster-scoped nodes are in the empty namespace.
+// Relationships across namespaces are dropped, unless edges is true, then they are kept in both graphs with the node of the other namespace.
+func (g *Graph) Split(edges bool) map[string]*Graph {
+	graphs := make(map[string]*Graph)
+
+	for namespace, nodes := range g.NodeListByNamespace() {
+		uids := make(map[types.UID]bool)
+		for _, node := range nodes {
+			uids[node.UID] = true
+		}
+
+		keep := make(map[types.UID]bool)
+		for uid := range uids {
+			keep[uid] = true
+		}
+		if edges {
+			for _, r := range g.RelationshipList() {
+				if uids[r.From] || uids[r.To] {
+					keep[r.From], keep[r.To] = true, true
+				}
 			}
 		}
+
+		sub := NewGraph(g.clientset)
+		sub.Options = g.Options
+		for uid := range keep {
+			if node, ok := g.Nodes[uid]; ok {
+				sub.Nodes[uid] = node
+			}
+			if g.roots[uid] {
+				sub.roots[uid] = true
+			}
+		}
+		for _, r := range g.RelationshipList() {
+			if sub.Nodes[r.From] != nil && sub.Nodes[r.To] != nil {
+				sub.Relationships[r.To] = append(sub.Relationships[r.To], r)
+			}
+		}
+
+		graphs[namespace] = sub
+	}
+
+	return graphs
+}
+
+// Relationship creates a new relationship between two nodes.
+// Relationships are unique by source, label and target, so the existing relationship is returned on repeated calls.
+func (g *Graph) Relationship(from *Node, label string, to *Node) *Relationship {
+	if r := g.lookup(from.GetUID(), label, to.GetUID()); r != nil {
+		return r
 	}
 
 	relationship := &Relationship{
@@ -316,7 +1212,17 @@ func (g *Graph) Relationship(from *Node, label string, to *Node) *Relationship {
 	return relationship
 }
 
-// RelationshipList returns a list of all relationships.
+// RelationshipUndirected creates a new labeled relationship between two nodes, which has no direction like a service and its pods.
+// Output formats which cannot express undirected relationships write it as a single directed relationship from the first node.
+func (g *Graph) RelationshipUndirected(from *Node, label string, to *Node) *Relationship {
+	r := g.Relationship(from, label, to)
+	r.Undirected = true
+
+	return r.Attribute("dir", "none")
+}
+
+// RelationshipList returns a list of all relationships sorted by source, target and label.
+// All output formats iterate the relationships in this order, so the same objects always result in the same output.
 func (g *Graph) RelationshipList() []*Relationship {
 	relationships := []*Relationship{}
 
@@ -324,9 +1230,335 @@ func (g *Graph) RelationshipList() []*Relationship {
 		relationships = append(relationships, relationship...)
 	}
 
+	sort.Slice(relationships, func(i, j int) bool {
+		a, b := relationships[i], relationships[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.Label < b.Label
+	})
+
 	return relationships
 }
 
+// Limit removes all nodes which are more than depth relationships away from the listed objects.
+// Relationships are followed in both directions, so a depth of 0 keeps only the listed objects.
+func (g *Graph) Limit(depth int) {
+	neighbours := make(map[types.UID][]types.UID)
+	for _, r := range g.RelationshipList() {
+		neighbours[r.From] = append(neighbours[r.From], r.To)
+		neighbours[r.To] = append(neighbours[r.To], r.From)
+	}
+
+	visited := make(map[types.UID]bool)
+	queue := []types.UID{}
+	for uid := range g.roots {
+		if _, ok := g.Nodes[uid]; ok {
+			visited[uid] = true
+			queue = append(queue, uid)
+		}
+	}
+
+	for i := 0; i < depth && len(queue) != 0; i++ {
+		next := []types.UID{}
+		for _, uid := range queue {
+			for _, neighbour := range neighbours[uid] {
+				if !visited[neighbour] {
+					visited[neighbour] = true
+					next = append(next, neighbour)
+				}
+			}
+		}
+		queue = next
+	}
+
+	g.retain(visited)
+}
+
+// Truncate removes all nodes but the first max nodes and returns the number of removed nodes.
+// The listed objects are kept first, followed by the nodes in order of their distance to the listed objects.
+func (g *Graph) Truncate(max int) int {
+	if len(g.Nodes) <= max {
+		return 0
+	}
+
+	neighbours := make(map[types.UID][]types.UID)
+	for _, r := range g.RelationshipList() {
+		neighbours[r.From] = append(neighbours[r.From], r.To)
+		neighbours[r.To] = append(neighbours[r.To], r.From)
+	}
+
+	nodes := g.NodeList()
+	visited := make(map[types.UID]bool)
+	queue := []types.UID{}
+	for _, n := range nodes {
+		if g.roots[n.UID] {
+			visited[n.UID] = true
+			queue = append(queue, n.UID)
+		}
+	}
+
+	for i := 0; i < len(queue); i++ {
+		for _, neighbour := range neighbours[queue[i]] {
+			if _, ok := g.Nodes[neighbour]; ok && !visited[neighbour] {
+				visited[neighbour] = true
+				queue = append(queue, neighbour)
+			}
+		}
+	}
+
+	for _, n := range nodes {
+		if !visited[n.UID] {
+			queue = append(queue, n.UID)
+		}
+	}
+
+	kept := make(map[types.UID]bool)
+	for _, uid := range queue[:max] {
+		kept[uid] = true
+	}
+	removed := len(g.Nodes) - max
+	g.retain(kept)
+
+	return removed
+}
+
+// Aggregate collapses all nodes of the same kind into a single node, which is named by the kind.
+// Relationships between the kinds are weighted by the number of relationships between their nodes.
+func (g *Graph) Aggregate() {
+	nodes, relationships, roots := g.Nodes, g.RelationshipList(), g.roots
+
+	g.Nodes = make(map[types.UID]*Node)
+	g.Relationships = make(map[types.UID][]*Relationship)
+	g.references = make(map[types.UID]reference)
+	g.roots = make(map[types.UID]bool)
+
+	kinds := make(map[types.UID]*Node)
+	for uid, node := range nodes {
+		gv, _ := schema.ParseGroupVersion(node.APIVersion)
+		gvk := gv.WithKind(node.Kind)
+
+		kinds[uid] = g.Node(gvk, &metav1.ObjectMeta{UID: ToUID("Kind", gvk.Group, gvk.Kind), Name: gvk.Kind})
+		if roots[uid] {
+			g.roots[kinds[uid].UID] = true
+		}
+	}
+
+	weights := make(map[*Relationship]int)
+	for _, r := range relationships {
+		from, to := kinds[r.From], kinds[r.To]
+		if from == nil || to == nil {
+			continue
+		}
+
+		relationship := g.Relationship(from, r.Label, to)
+		weights[relationship]++
+		relationship.Attribute("weight", fmt.Sprint(weights[relationship]))
+	}
+}
+
+// TreeNode represents a node at a depth of the tree with the label of the relationship from its parent.
+// A node which is already printed elsewhere in the tree is marked as visited and not descended again.
+type TreeNode struct {
+	*Node
+	Depth   int
+	Label   string
+	Visited bool
+}
+
+// Tree returns all nodes in depth first order starting from the nodes without incoming relationships.
+// Siblings are sorted by kind and name. Nodes which are only reachable by a cycle are used as additional roots.
+func (g *Graph) Tree() []*TreeNode {
+	children := make(map[types.UID][]*Relationship)
+	for _, r := range g.RelationshipList() {
+		if _, ok := g.Nodes[r.To]; ok {
+			children[r.From] = append(children[r.From], r)
+		}
+	}
+
+	less := func(a, b *Node) bool {
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	}
+	for _, rs := range children {
+		sort.SliceStable(rs, func(i, j int) bool {
+			return less(g.Nodes[rs[i].To], g.Nodes[rs[j].To])
+		})
+	}
+
+	nodes := g.NodeList()
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return less(nodes[i], nodes[j])
+	})
+
+	tree := []*TreeNode{}
+	visited := make(map[types.UID]bool)
+
+	var walk func(n *Node, depth int, label string)
+	walk = func(n *Node, depth int, label string) {
+		tree = append(tree, &TreeNode{Node: n, Depth: depth, Label: label, Visited: visited[n.UID]})
+		if visited[n.UID] {
+			return
+		}
+		visited[n.UID] = true
+
+		for _, r := range children[n.UID] {
+			walk(g.Nodes[r.To], depth+1, r.Label)
+		}
+	}
+
+	for _, n := range nodes {
+		if len(g.Relationships[n.UID]) == 0 {
+			walk(n, 0, "")
+		}
+	}
+	for _, n := range nodes {
+		if !visited[n.UID] {
+			walk(n, 0, "")
+		}
+	}
+
+	return tree
+}
+
+// PruneOrphans removes all nodes without any relationship, except the listed objects.
+// The relationships of the cluster and namespaces to their members, added by Finalize, are not counted,
+// so the cluster and namespaces are only kept while they have a remaining member or another relationship.
+func (g *Graph) PruneOrphans() {
+	connected := make(map[types.UID]bool)
+	for uid := range g.roots {
+		connected[uid] = true
+	}
+
+	memberships := []*Relationship{}
+	for _, r := range g.RelationshipList() {
+		if g.membership(r) {
+			memberships = append(memberships, r)
+			continue
+		}
+		connected[r.From], connected[r.To] = true, true
+	}
+
+	// The namespaces are kept for their members first, afterwards the cluster is kept for its namespaces.
+	for _, kind := range []string{"Namespace", "Cluster"} {
+		for _, r := range memberships {
+			if g.Nodes[r.From].Kind == kind && connected[r.To] {
+				connected[r.From] = true
+			}
+		}
+	}
+
+	g.retain(connected)
+}
+
+// membership returns true if the relationship relates the cluster or a namespace to a member node.
+// These relationships are labeled with the kind of the member.
+func (g *Graph) membership(r *Relationship) bool {
+	from, to := g.Nodes[r.From], g.Nodes[r.To]
+	if from == nil || to == nil {
+		return false
+	}
+
+	return (from.Kind == "Cluster" || from.Kind == "Namespace") && r.Label == to.Kind
+}
+
+// Cycles returns all cycles of the Graph, each as the list of nodes from the first node back to the first node.
+// Every cycle is closed by a back edge of a depth first search in the order of the node and relationship lists.
+func (g *Graph) Cycles() [][]*Node {
+	_, cycles := g.backEdges()
+
+	return cycles
+}
+
+// BreakCycles removes the back edge of each cycle and returns the removed relationships.
+// The same relationships are removed on each run, so the result is deterministic.
+func (g *Graph) BreakCycles() []*Relationship {
+	edges, _ := g.backEdges()
+
+	for _, edge := range edges {
+		kept := []*Relationship{}
+		for _, r := range g.Relationships[edge.To] {
+			if r != edge {
+				kept = append(kept, r)
+			}
+		}
+		g.Relationships[edge.To] = kept
+	}
+
+	return edges
+}
+
+// backEdges returns all relationships to a node on the current path of a depth first search and the closed cycles.
+func (g *Graph) backEdges() ([]*Relationship, [][]*Node) {
+	children := make(map[types.UID][]*Relationship)
+	for _, r := range g.RelationshipList() {
+		if _, ok := g.Nodes[r.To]; ok {
+			children[r.From] = append(children[r.From], r)
+		}
+	}
+
+	edges, cycles := []*Relationship{}, [][]*Node{}
+	onPath, visited := make(map[types.UID]int), make(map[types.UID]bool)
+	path := []*Node{}
+
+	var visit func(n *Node)
+	visit = func(n *Node) {
+		visited[n.UID] = true
+		onPath[n.UID] = len(path)
+		path = append(path, n)
+
+		for _, r := range children[n.UID] {
+			if i, ok := onPath[r.To]; ok {
+				edges = append(edges, r)
+				cycles = append(cycles, append(append([]*Node{}, path[i:]...), g.Nodes[r.To]))
+				continue
+			}
+			if !visited[r.To] {
+				visit(g.Nodes[r.To])
+			}
+		}
+
+		path = path[:len(path)-1]
+		delete(onPath, n.UID)
+	}
+
+	for _, n := range g.NodeList() {
+		if !visited[n.UID] {
+			visit(n)
+		}
+	}
+
+	return edges, cycles
+}
+
+// retain removes all nodes and their relationships which are not in the given set.
+func (g *Graph) retain(uids map[types.UID]bool) {
+	for uid := range g.Nodes {
+		if !uids[uid] {
+			delete(g.Nodes, uid)
+		}
+	}
+
+	for to, rs := range g.Relationships {
+		if !uids[to] {
+			delete(g.Relationships, to)
+			continue
+		}
+		kept := []*Relationship{}
+		for _, r := range rs {
+			if uids[r.From] {
+				kept = append(kept, r)
+			}
+		}
+		g.Relationships[to] = kept
+	}
+}
+
 // Attribute adds an attribute to a relationship.
 func (r *Relationship) Attribute(key string, value string) *Relationship {
 	r.Attr[key] = value
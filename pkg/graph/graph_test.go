@@ -0,0 +1,589 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// newTestNode adds a node of the kind to the Graph, whose UID is derived from the kind, namespace and name.
+func newTestNode(g *Graph, kind string, namespace string, name string) *Node {
+	return g.Node(
+		schema.FromAPIVersionAndKind("v1", kind),
+		&metav1.ObjectMeta{
+			UID:       ToUID(kind, namespace, name),
+			Namespace: namespace,
+			Name:      name,
+		},
+	)
+}
+
+// newTestGraph creates a Graph with a client of a fake API server, which responds with the JSON body of the request path.
+// All access reviews are allowed and all other paths are not found.
+func newTestGraph(t *testing.T, responses map[string]string) *Graph {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/apis/authorization.k8s.io/v1/selfsubjectaccessreviews" {
+			w.Write([]byte(`{"kind": "SelfSubjectAccessReview", "apiVersion": "authorization.k8s.io/v1", "status": {"allowed": true}}`))
+			return
+		}
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"kind": "Status", "apiVersion": "v1", "status": "Failure", "reason": "NotFound", "code": 404}`))
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	return NewGraph(kubernetes.NewForConfigOrDie(&rest.Config{Host: server.URL}))
+}
+
+func TestGraphMLIsValidXML(t *testing.T) {
+	g := NewGraph(nil)
+	deployment := newTestNode(g, "Deployment", "default", `web<&"'>`)
+	pod := newTestNode(g, "Pod", "default", "web-1")
+	cluster := newTestNode(g, "Cluster", "", "kind")
+	g.Relationship(deployment, RelationshipOwns, pod)
+	g.Relationship(cluster, "<Namespace>", pod)
+
+	var doc struct {
+		Keys []struct {
+			ID  string `xml:"id,attr"`
+			For string `xml:"for,attr"`
+		} `xml:"key"`
+		Graph struct {
+			Nodes []struct {
+				ID   string `xml:"id,attr"`
+				Data []struct {
+					Key   string `xml:"key,attr"`
+					Value string `xml:",chardata"`
+				} `xml:"data"`
+			} `xml:"node"`
+			Edges []struct {
+				Source string `xml:"source,attr"`
+				Target string `xml:"target,attr"`
+				Data   []struct {
+					Key   string `xml:"key,attr"`
+					Value string `xml:",chardata"`
+				} `xml:"data"`
+			} `xml:"edge"`
+		} `xml:"graph"`
+	}
+	if err := xml.Unmarshal([]byte(g.String("graphml")), &doc); err != nil {
+		t.Fatalf("graphml output is not valid xml: %v", err)
+	}
+
+	if len(doc.Keys) != 4 {
+		t.Errorf("expected 4 keys, got %d", len(doc.Keys))
+	}
+	if len(doc.Graph.Nodes) != 3 {
+		t.Fatalf("expected 3 nodes, got %d", len(doc.Graph.Nodes))
+	}
+	if len(doc.Graph.Edges) != 2 {
+		t.Fatalf("expected 2 edges, got %d", len(doc.Graph.Edges))
+	}
+
+	names := map[string]string{}
+	for _, node := range doc.Graph.Nodes {
+		for _, data := range node.Data {
+			if data.Key == "name" {
+				names[node.ID] = data.Value
+			}
+		}
+	}
+	if name := names[string(deployment.UID)]; name != deployment.Name {
+		t.Errorf("expected name %q, got %q", deployment.Name, name)
+	}
+
+	labels := map[string]bool{}
+	for _, edge := range doc.Graph.Edges {
+		if _, ok := names[edge.Source]; !ok {
+			t.Errorf("unknown edge source %q", edge.Source)
+		}
+		if _, ok := names[edge.Target]; !ok {
+			t.Errorf("unknown edge target %q", edge.Target)
+		}
+		for _, data := range edge.Data {
+			labels[data.Value] = true
+		}
+	}
+	for _, label := range []string{RelationshipOwns, "<Namespace>"} {
+		if !labels[label] {
+			t.Errorf("expected edge label %q", label)
+		}
+	}
+}
+
+func TestBuildKeepsObjectsWhichSucceeded(t *testing.T) {
+	// The endpoints of the service are not found, but the request for its pods fails.
+	g := newTestGraph(t, map[string]string{})
+
+	service := &unstructured.Unstructured{}
+	service.SetAPIVersion("v1")
+	service.SetKind("Service")
+	service.SetNamespace("default")
+	service.SetName("web")
+	service.SetUID("service")
+	unstructured.SetNestedStringMap(service.Object, map[string]string{"app": "web"}, "spec", "selector")
+
+	configMap := &unstructured.Unstructured{}
+	configMap.SetAPIVersion("v1")
+	configMap.SetKind("ConfigMap")
+	configMap.SetNamespace("default")
+	configMap.SetName("config")
+	configMap.SetUID("config")
+
+	_, err := g.Build(context.Background(), []*unstructured.Unstructured{service, configMap})
+	if err == nil {
+		t.Fatal("expected the error of the service to be returned")
+	}
+	if _, ok := g.Nodes["config"]; !ok {
+		t.Error("expected the config map to be graphed despite the failed service")
+	}
+}
+
+func TestReferrersKeepsOnlyWorkloadsAndPods(t *testing.T) {
+	g := newTestGraph(t, map[string]string{
+		"/api/v1/namespaces/default/pods":              `{"kind": "PodList", "apiVersion": "v1", "items": [{"metadata": {"name": "web-1", "namespace": "default", "uid": "pod"}}]}`,
+		"/apis/apps/v1/namespaces/default/replicasets": `{"kind": "ReplicaSetList", "apiVersion": "apps/v1", "items": []}`,
+	})
+
+	configMap := &unstructured.Unstructured{}
+	configMap.SetAPIVersion("v1")
+	configMap.SetKind("ConfigMap")
+	configMap.SetNamespace("default")
+	configMap.SetName("config")
+	configMap.SetUID("config")
+
+	volumes := []interface{}{map[string]interface{}{"name": "config", "configMap": map[string]interface{}{"name": "config"}}}
+
+	pod := &unstructured.Unstructured{}
+	pod.SetAPIVersion("v1")
+	pod.SetKind("Pod")
+	pod.SetNamespace("default")
+	pod.SetName("web-1")
+	pod.SetUID("pod")
+	unstructured.SetNestedSlice(pod.Object, volumes, "spec", "volumes")
+
+	deployment := &unstructured.Unstructured{}
+	deployment.SetAPIVersion("apps/v1")
+	deployment.SetKind("Deployment")
+	deployment.SetNamespace("default")
+	deployment.SetName("web")
+	deployment.SetUID("deployment")
+	unstructured.SetNestedField(deployment.Object, int64(0), "spec", "replicas")
+	unstructured.SetNestedStringMap(deployment.Object, map[string]string{"app": "web"}, "spec", "selector", "matchLabels")
+	unstructured.SetNestedSlice(deployment.Object, volumes, "spec", "template", "spec", "volumes")
+
+	service := &unstructured.Unstructured{}
+	service.SetAPIVersion("v1")
+	service.SetKind("Service")
+	service.SetNamespace("default")
+	service.SetName("web")
+	service.SetUID("service")
+	unstructured.SetNestedStringMap(service.Object, map[string]string{"app": "web"}, "spec", "selector")
+
+	if _, err := g.Build(context.Background(), []*unstructured.Unstructured{configMap}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Referrers(context.Background(), []*unstructured.Unstructured{pod, deployment, service}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, uid := range []types.UID{"config", "pod", "deployment"} {
+		if _, ok := g.Nodes[uid]; !ok {
+			t.Errorf("expected the node %q to be kept", uid)
+		}
+	}
+	for _, n := range g.Nodes {
+		switch n.Kind {
+		case "ConfigMap", "Pod", "Deployment":
+		default:
+			t.Errorf("expected the %s %q not to be kept as referrer", n.Kind, n.Name)
+		}
+	}
+}
+
+func TestRelationshipIsIdempotent(t *testing.T) {
+	g := NewGraph(nil)
+	deployment := newTestNode(g, "Deployment", "default", "web")
+	pod := newTestNode(g, "Pod", "default", "web-1")
+
+	first := g.Relationship(deployment, RelationshipOwns, pod)
+	second := g.Relationship(deployment, RelationshipOwns, pod)
+	if first != second {
+		t.Error("expected the existing relationship to be returned")
+	}
+	if n := len(g.RelationshipList()); n != 1 {
+		t.Errorf("expected 1 relationship, got %d", n)
+	}
+
+	g.Relationship(deployment, RelationshipSelects, pod)
+	if n := len(g.RelationshipList()); n != 2 {
+		t.Errorf("expected a relationship with another label to be added, got %d relationships", n)
+	}
+}
+
+func TestCypherEscapesStrings(t *testing.T) {
+	g := NewGraph(nil)
+	secret := newTestNode(g, "Secret", "default", "a\"b\\c\nd")
+	pod := newTestNode(g, "Pod", "default", "web-1")
+	g.Relationship(pod, "ref`erences", secret)
+
+	out := g.String("cypher")
+
+	for _, want := range []string{
+		`node.Name = "a\"b\\c\nd"`,
+		"-[:`ref``erences`]->",
+		"MERGE (node:`Secret`:k8s",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected the cypher output to contain %s", want)
+		}
+	}
+
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "MERGE") && !strings.HasSuffix(line, ";") {
+			t.Errorf("expected each statement on a single line, got %q", line)
+		}
+	}
+}
+
+func TestD3NodeIDsAreUnique(t *testing.T) {
+	g := NewGraph(nil)
+	// Containers with the same name in different pods have the same namespace, kind and name.
+	first := newTestNode(g, "Pod", "default", "web-1")
+	second := newTestNode(g, "Pod", "default", "web-2")
+	for _, pod := range []*Node{first, second} {
+		container := g.Node(
+			schema.FromAPIVersionAndKind("v1", "Container"),
+			&metav1.ObjectMeta{UID: ToUID(pod.UID, "nginx"), Namespace: "default", Name: "nginx"},
+		)
+		g.Relationship(pod, "Container", container)
+	}
+
+	var doc struct {
+		Nodes []struct {
+			ID    string `json:"id"`
+			Name  string `json:"name"`
+			Group int    `json:"group"`
+		} `json:"nodes"`
+		Links []struct {
+			Source string `json:"source"`
+			Target string `json:"target"`
+		} `json:"links"`
+	}
+	if err := json.Unmarshal([]byte(g.String("d3")), &doc); err != nil {
+		t.Fatalf("d3 output is not valid json: %v", err)
+	}
+
+	ids := map[string]bool{}
+	for _, node := range doc.Nodes {
+		if ids[node.ID] {
+			t.Errorf("duplicate node id %q of %q", node.ID, node.Name)
+		}
+		ids[node.ID] = true
+	}
+	if len(ids) != 4 {
+		t.Errorf("expected 4 nodes, got %d", len(ids))
+	}
+
+	targets := map[string]bool{}
+	for _, link := range doc.Links {
+		if !ids[link.Source] || !ids[link.Target] {
+			t.Errorf("link %s -> %s references an unknown node", link.Source, link.Target)
+		}
+		targets[link.Target] = true
+	}
+	if len(targets) != 2 {
+		t.Errorf("expected the links to target 2 distinct containers, got %d", len(targets))
+	}
+}
+
+func TestBreakCyclesRemovesBackEdge(t *testing.T) {
+	g := NewGraph(nil)
+	a := newTestNode(g, "ConfigMap", "default", "a")
+	b := newTestNode(g, "ConfigMap", "default", "b")
+	g.Relationship(a, RelationshipReferences, b)
+	g.Relationship(b, RelationshipReferences, a)
+
+	cycles := g.Cycles()
+	if len(cycles) != 1 {
+		t.Fatalf("expected 1 cycle, got %d", len(cycles))
+	}
+	if cycle := cycles[0]; len(cycle) != 3 || cycle[0] != cycle[2] {
+		t.Errorf("expected the cycle to return to its first node, got %v", cycle)
+	}
+
+	removed := g.BreakCycles()
+	if len(removed) != 1 {
+		t.Fatalf("expected 1 removed relationship, got %d", len(removed))
+	}
+	if len(g.Cycles()) != 0 {
+		t.Error("expected no cycle after breaking the cycles")
+	}
+	if n := len(g.RelationshipList()); n != 1 {
+		t.Errorf("expected 1 relationship to be kept, got %d", n)
+	}
+}
+
+func TestPruneOrphansIgnoresMemberships(t *testing.T) {
+	g := NewGraph(nil)
+	cluster := newTestNode(g, "Cluster", "", "kind")
+	namespace := newTestNode(g, "Namespace", "", "default")
+	empty := newTestNode(g, "Namespace", "", "empty")
+	deployment := newTestNode(g, "Deployment", "default", "web")
+	pod := newTestNode(g, "Pod", "default", "web-1")
+	configMap := newTestNode(g, "ConfigMap", "default", "orphan")
+	secret := newTestNode(g, "Secret", "empty", "orphan")
+
+	// The memberships are added like by Finalize to all nodes without a relationship to them.
+	g.Relationship(cluster, "Namespace", namespace)
+	g.Relationship(cluster, "Namespace", empty)
+	g.Relationship(namespace, "Deployment", deployment)
+	g.Relationship(namespace, "ConfigMap", configMap)
+	g.Relationship(empty, "Secret", secret)
+	g.Relationship(deployment, RelationshipOwns, pod)
+
+	g.PruneOrphans()
+
+	for _, n := range []*Node{cluster, namespace, deployment, pod} {
+		if _, ok := g.Nodes[n.UID]; !ok {
+			t.Errorf("expected the %s %q to be kept", n.Kind, n.Name)
+		}
+	}
+	for _, n := range []*Node{empty, configMap, secret} {
+		if _, ok := g.Nodes[n.UID]; ok {
+			t.Errorf("expected the %s %q to be pruned", n.Kind, n.Name)
+		}
+	}
+}
+
+func TestOutputIsDeterministic(t *testing.T) {
+	// The same objects and relationships are added in a different order to each graph.
+	build := func(reverse bool) *Graph {
+		g := NewGraph(nil)
+		kinds := []string{"Deployment", "ReplicaSet", "Pod", "ConfigMap", "Secret"}
+		if reverse {
+			for i, j := 0, len(kinds)-1; i < j; i, j = i+1, j-1 {
+				kinds[i], kinds[j] = kinds[j], kinds[i]
+			}
+		}
+		nodes := map[string]*Node{}
+		for _, kind := range kinds {
+			nodes[kind] = newTestNode(g, kind, "default", "web")
+		}
+
+		relationships := [][2]string{{"Deployment", "ReplicaSet"}, {"ReplicaSet", "Pod"}, {"Pod", "ConfigMap"}, {"Pod", "Secret"}}
+		if reverse {
+			for i, j := 0, len(relationships)-1; i < j; i, j = i+1, j-1 {
+				relationships[i], relationships[j] = relationships[j], relationships[i]
+			}
+		}
+		for _, r := range relationships {
+			g.Relationship(nodes[r[0]], RelationshipReferences, nodes[r[1]])
+		}
+
+		return g
+	}
+
+	for _, format := range []string{"arangodb", "csv", "cypher", "d3", "gexf", "graphml", "graphviz", "gremlin", "json", "mermaid", "tree"} {
+		t.Run(format, func(t *testing.T) {
+			first, second := build(false).String(format), build(true).String(format)
+			if len(first) == 0 {
+				t.Fatal("expected a non-empty output")
+			}
+			if first != second {
+				t.Errorf("expected byte-identical output, got:\n%s\nand:\n%s", first, second)
+			}
+		})
+	}
+}
+
+func TestNodeIsDeduplicatedByUID(t *testing.T) {
+	g := newTestGraph(t, map[string]string{})
+
+	configMap := &unstructured.Unstructured{}
+	configMap.SetAPIVersion("v1")
+	configMap.SetKind("ConfigMap")
+	configMap.SetNamespace("default")
+	configMap.SetName("config")
+	configMap.SetUID("config")
+	configMap.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", UID: "deployment"}})
+
+	first, err := g.Unstructured(configMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := g.Unstructured(configMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first != second {
+		t.Error("expected the existing node to be returned")
+	}
+	if n := len(g.Nodes); n != 2 {
+		t.Errorf("expected 2 nodes for the object and its owner, got %d", n)
+	}
+	if n := len(g.RelationshipList()); n != 1 {
+		t.Errorf("expected 1 relationship to the owner, got %d", n)
+	}
+}
+
+func TestNodeDistinguishesControllingOwner(t *testing.T) {
+	g := NewGraph(nil)
+	controller := true
+	pod := &metav1.ObjectMeta{
+		UID:       "pod",
+		Namespace: "default",
+		Name:      "web-1",
+		OwnerReferences: []metav1.OwnerReference{
+			{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web", UID: "replicaset", Controller: &controller},
+			{APIVersion: "v1", Kind: "ConfigMap", Name: "owner", UID: "configmap"},
+		},
+	}
+	g.Node(schema.FromAPIVersionAndKind("v1", "Pod"), pod)
+
+	controls := g.lookup("replicaset", RelationshipControls, "pod")
+	if controls == nil {
+		t.Fatal("expected the replica set to control the pod")
+	}
+	if style := controls.Attr["style"]; len(style) != 0 {
+		t.Errorf("expected the controlling owner to be solid, got style %q", style)
+	}
+
+	owns := g.lookup("configmap", RelationshipOwns, "pod")
+	if owns == nil {
+		t.Fatal("expected the config map to own the pod")
+	}
+	if style := owns.Attr["style"]; style != "dashed" {
+		t.Errorf("expected the non-controlling owner to be dashed, got style %q", style)
+	}
+
+	if n := len(g.Relationships["pod"]); n != 2 {
+		t.Errorf("expected 2 relationships to the pod, got %d", n)
+	}
+}
+
+func TestListChunksFollowsContinue(t *testing.T) {
+	g := newTestGraph(t, map[string]string{})
+	g.Options.ChunkSize = 2
+
+	chunks := map[string]*v1.PodList{
+		"":       {ListMeta: metav1.ListMeta{Continue: "second"}, Items: []v1.Pod{{}, {}}},
+		"second": {Items: []v1.Pod{{}}},
+	}
+	list := func(ctx context.Context, options metav1.ListOptions) (*v1.PodList, error) {
+		if options.Limit != 2 {
+			t.Errorf("expected a limit of 2, got %d", options.Limit)
+		}
+		return chunks[options.Continue], nil
+	}
+
+	attr := authorizationv1.ResourceAttributes{Namespace: "default", Verb: "list", Resource: "pods"}
+	items, err := listChunks(g, attr, metav1.ListOptions{}, list, func(list *v1.PodList) []v1.Pod { return list.Items })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 3 {
+		t.Errorf("expected 3 items of both chunks, got %d", len(items))
+	}
+}
+
+func TestCSVQuotesFields(t *testing.T) {
+	g := NewGraph(nil)
+	deployment := newTestNode(g, "Deployment", "default", `web,"api"`)
+	pod := newTestNode(g, "Pod", "default", "web-1")
+	g.Relationship(deployment, RelationshipOwns, pod)
+
+	records, err := csv.NewReader(strings.NewReader(g.String("csv"))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected a header and 1 row, got %d records", len(records))
+	}
+
+	expected := []string{"Deployment", "default", `web,"api"`, RelationshipOwns, "Pod", "default", "web-1"}
+	if row := records[1]; !reflect.DeepEqual(row, expected) {
+		t.Errorf("expected row %q, got %q", expected, row)
+	}
+}
+
+func TestAnnotationReferences(t *testing.T) {
+	deployment := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+
+	tests := []struct {
+		name     string
+		value    string
+		expected []types.UID
+	}{
+		{"name of the same kind", "web-2", []types.UID{ToUID("ConfigMap", "default", "web-2")}},
+		{"kind and name", "deployment/web", []types.UID{"deployment-web"}},
+		{"comma separated", "web-2, Deployment/web", []types.UID{ToUID("ConfigMap", "default", "web-2"), "deployment-web"}},
+		{"placeholder resolved later", "deployment/api", []types.UID{"deployment-api"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGraph(nil)
+			g.Options.EdgeAnnotations = []string{"relatedTo"}
+
+			n := g.Node(schema.FromAPIVersionAndKind("v1", "ConfigMap"), &metav1.ObjectMeta{
+				UID:         ToUID("ConfigMap", "default", "web-1"),
+				Namespace:   "default",
+				Name:        "web-1",
+				Annotations: map[string]string{"relatedTo": tt.value},
+			})
+			newTestNode(g, "ConfigMap", "default", "web-2")
+			g.Node(deployment, &metav1.ObjectMeta{UID: "deployment-web", Namespace: "default", Name: "web"})
+
+			g.AnnotationReferences()
+
+			// The deployment api is added after the references were created, like by a later build.
+			g.Node(deployment, &metav1.ObjectMeta{UID: "deployment-api", Namespace: "default", Name: "api"})
+			g.ResolveReferences()
+
+			for _, uid := range tt.expected {
+				if g.lookup(n.UID, "relatedTo", uid) == nil {
+					t.Errorf("expected a relationship to %q", uid)
+				}
+			}
+			if n := len(g.RelationshipList()); n != len(tt.expected) {
+				t.Errorf("expected %d relationships, got %d", len(tt.expected), n)
+			}
+		})
+	}
+}
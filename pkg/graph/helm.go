@@ -0,0 +1,139 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// HelmReleaseSecretType is the type of the secrets used by helm to store the state of a release.
+const HelmReleaseSecretType v1.SecretType = "helm.sh/release.v1"
+
+// HelmV3Graph is used to graph all helm releases.
+type HelmV3Graph struct {
+	graph *Graph
+}
+
+// NewHelmV3Graph creates a new HelmV3Graph.
+func NewHelmV3Graph(g *Graph) *HelmV3Graph {
+	return &HelmV3Graph{
+		graph: g,
+	}
+}
+
+// HelmV3 retrieves the HelmV3Graph.
+func (g *Graph) HelmV3() *HelmV3Graph {
+	return g.helmV3
+}
+
+// helmRelease represents the fields of a helm release which are needed to graph it.
+type helmRelease struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Version   int    `json:"version"`
+	Manifest  string `json:"manifest"`
+	Info      struct {
+		Status string `json:"status"`
+	} `json:"info"`
+}
+
+// ReleaseSecret adds a synthetic HelmRelease to the Graph if the secret stores the deployed revision of a release.
+// Secrets of superseded or failed revisions are ignored, so only the latest deployed revision is graphed.
+func (g *HelmV3Graph) ReleaseSecret(secret *Node, obj *v1.Secret) (*Node, error) {
+	if obj.Type != HelmReleaseSecretType || obj.GetLabels()["status"] != "deployed" {
+		return nil, nil
+	}
+
+	release, err := g.decode(obj.Data["release"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode helm release of secret %s/%s: %v", obj.GetNamespace(), obj.GetName(), err)
+	}
+
+	n := g.graph.Node(
+		schema.FromAPIVersionAndKind("kubectl-graph/v1", "HelmRelease"),
+		&metav1.ObjectMeta{
+			UID:       ToUID("HelmRelease", release.Namespace, release.Name),
+			Namespace: release.Namespace,
+			Name:      release.Name,
+		},
+	)
+	g.graph.Relationship(n, fmt.Sprintf("revision %d", release.Version), secret)
+
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewBufferString(release.Manifest), 4096)
+	for {
+		manifest := &unstructured.Unstructured{}
+		if err := decoder.Decode(&manifest.Object); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to decode manifest of helm release %s/%s: %v", release.Namespace, release.Name, err)
+		}
+		if len(manifest.GetKind()) == 0 || len(manifest.GetName()) == 0 {
+			continue
+		}
+
+		// Helm installs namespaced objects without a namespace into the namespace of the release,
+		// while cluster-scoped objects never have a namespace.
+		namespace := manifest.GetNamespace()
+		switch {
+		case !g.graph.Namespaced(manifest.GroupVersionKind()):
+			namespace = ""
+		case len(namespace) == 0:
+			namespace = release.Namespace
+		}
+
+		r := g.graph.Reference(manifest.GroupVersionKind(), namespace, manifest.GetName())
+		g.graph.Relationship(n, "manages", r)
+	}
+
+	return n, nil
+}
+
+// decode returns the helm release of a secret, which is base64 encoded and usually gzip compressed JSON.
+func (g *HelmV3Graph) decode(data []byte) (*helmRelease, error) {
+	b, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.HasPrefix(b, []byte{0x1f, 0x8b}) {
+		r, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+
+		if b, err = io.ReadAll(r); err != nil {
+			return nil, err
+		}
+	}
+
+	release := &helmRelease{}
+	if err := json.Unmarshal(b, release); err != nil {
+		return nil, err
+	}
+
+	return release, nil
+}
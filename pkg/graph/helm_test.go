@@ -0,0 +1,72 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestReleaseSecretReferencesClusterScopedObjects(t *testing.T) {
+	g := newTestGraph(t, map[string]string{
+		"/api/v1": `{"kind": "APIResourceList", "groupVersion": "v1", "resources": [
+			{"name": "configmaps", "kind": "ConfigMap", "namespaced": true, "verbs": ["list"]}
+		]}`,
+		"/apis/rbac.authorization.k8s.io/v1": `{"kind": "APIResourceList", "groupVersion": "rbac.authorization.k8s.io/v1", "resources": [
+			{"name": "clusterroles", "kind": "ClusterRole", "namespaced": false, "verbs": ["list"]}
+		]}`,
+	})
+
+	release, err := json.Marshal(map[string]interface{}{
+		"name":      "web",
+		"namespace": "apps",
+		"version":   2,
+		"info":      map[string]string{"status": "deployed"},
+		"manifest": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: web\n---\n" +
+			"apiVersion: rbac.authorization.k8s.io/v1\nkind: ClusterRole\nmetadata:\n  name: web\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	obj := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "sh.helm.release.v1.web.v2", Namespace: "apps", UID: "secret", Labels: map[string]string{"status": "deployed"}},
+		Type:       HelmReleaseSecretType,
+		Data:       map[string][]byte{"release": []byte(base64.StdEncoding.EncodeToString(release))},
+	}
+	secret := newTestNode(g, "Secret", "apps", obj.Name)
+	n, err := g.HelmV3().ReleaseSecret(secret, obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clusterRole := g.Node(schema.FromAPIVersionAndKind("rbac.authorization.k8s.io/v1", "ClusterRole"), &metav1.ObjectMeta{UID: "cluster-role", Name: "web"})
+	configMap := g.Node(schema.FromAPIVersionAndKind("v1", "ConfigMap"), &metav1.ObjectMeta{UID: "config-map", Namespace: "apps", Name: "web"})
+	g.ResolveReferences()
+
+	for _, target := range []*Node{clusterRole, configMap} {
+		if g.lookup(n.UID, "manages", target.UID) == nil {
+			t.Errorf("expected the release to manage %s/%s", target.Kind, target.Name)
+		}
+	}
+	if len(g.references) != 0 {
+		t.Errorf("expected all references to be resolved, got %d placeholders", len(g.references))
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// IstioNetworkingGroupVersion is the group version of the istio networking resources.
+var IstioNetworkingGroupVersion = schema.GroupVersion{Group: "networking.istio.io", Version: "v1"}
+
+// IstioNetworkingV1Graph is used to graph all istio networking resources.
+type IstioNetworkingV1Graph struct {
+	graph *Graph
+}
+
+// NewIstioNetworkingV1Graph creates a new IstioNetworkingV1Graph.
+func NewIstioNetworkingV1Graph(g *Graph) *IstioNetworkingV1Graph {
+	return &IstioNetworkingV1Graph{
+		graph: g,
+	}
+}
+
+// IstioNetworkingV1 retrieves the IstioNetworkingV1Graph.
+func (g *Graph) IstioNetworkingV1() *IstioNetworkingV1Graph {
+	return g.istioNetworkingV1
+}
+
+// Unstructured adds an unstructured node to the Graph.
+func (g *IstioNetworkingV1Graph) Unstructured(unstr *unstructured.Unstructured) (*Node, error) {
+	switch unstr.GetKind() {
+	case "VirtualService":
+		return g.VirtualService(unstr)
+	case "DestinationRule":
+		return g.DestinationRule(unstr)
+	case "Gateway":
+		return g.Gateway(unstr)
+	default:
+		return g.graph.Node(unstr.GroupVersionKind(), unstr), nil
+	}
+}
+
+// VirtualService adds a VirtualService resource to the Graph, which is related to its gateways
+// and to the destination hosts of all http, tcp and tls routes.
+func (g *IstioNetworkingV1Graph) VirtualService(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	gateways, _, _ := unstructured.NestedStringSlice(obj.Object, "spec", "gateways")
+	for _, gateway := range gateways {
+		// The reserved gateway mesh represents all sidecars and not a Gateway resource.
+		if gateway == "mesh" {
+			continue
+		}
+
+		namespace, name := obj.GetNamespace(), gateway
+		if i := strings.Index(gateway, "/"); i >= 0 {
+			namespace, name = gateway[:i], gateway[i+1:]
+		}
+		r := g.graph.Reference(IstioNetworkingGroupVersion.WithKind("Gateway"), namespace, name)
+		g.graph.Relationship(n, "gateway", r)
+	}
+
+	for _, protocol := range []string{"http", "tcp", "tls"} {
+		routes, _, _ := unstructured.NestedSlice(obj.Object, "spec", protocol)
+		for _, route := range routes {
+			route, ok := route.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			destinations, _, _ := unstructured.NestedSlice(route, "route")
+			for _, destination := range destinations {
+				destination, ok := destination.(map[string]interface{})
+				if !ok {
+					continue
+				}
+
+				host, _, _ := unstructured.NestedString(destination, "destination", "host")
+				if h := g.Host(obj.GetNamespace(), host); h != nil {
+					g.graph.Relationship(n, "route", h)
+				}
+			}
+		}
+	}
+
+	return n, nil
+}
+
+// DestinationRule adds a DestinationRule resource to the Graph, which is related to its host.
+func (g *IstioNetworkingV1Graph) DestinationRule(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	host, _, _ := unstructured.NestedString(obj.Object, "spec", "host")
+	if h := g.Host(obj.GetNamespace(), host); h != nil {
+		g.graph.Relationship(n, "host", h)
+	}
+
+	return n, nil
+}
+
+// Gateway adds a Gateway resource to the Graph, which is related to the gateway pods matching its selector.
+// The selector matches pods in all namespaces, like istio does by default.
+func (g *IstioNetworkingV1Graph) Gateway(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	selector, _, _ := unstructured.NestedStringMap(obj.Object, "spec", "selector")
+	if len(selector) == 0 {
+		return n, nil
+	}
+
+	options := metav1.ListOptions{LabelSelector: labels.SelectorFromSet(selector).String(), FieldSelector: "status.phase=Running"}
+	pods, err := g.graph.CoreV1().PodList(metav1.NamespaceAll, options)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range pods.Items {
+		p, err := g.graph.CoreV1().Pod(&pods.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		g.graph.Relationship(n, RelationshipSelects, p)
+	}
+
+	return n, nil
+}
+
+// Host adds the Service of a host to the Graph. A short name like reviews is a Service within the namespace,
+// while a fully qualified name like reviews.prod.svc.cluster.local is a Service within its namespace.
+// All other hosts are external and added as a Host, while wildcard hosts are skipped.
+func (g *IstioNetworkingV1Graph) Host(namespace string, host string) *Node {
+	if len(host) == 0 || strings.Contains(host, "*") {
+		return nil
+	}
+
+	parts := strings.Split(host, ".")
+	switch {
+	case len(parts) == 1:
+		return g.graph.Reference(schema.FromAPIVersionAndKind(v1.GroupName, "Service"), namespace, host)
+	case len(parts) >= 3 && parts[2] == "svc":
+		return g.graph.Reference(schema.FromAPIVersionAndKind(v1.GroupName, "Service"), parts[1], parts[0])
+	}
+
+	h, _ := g.graph.NetworkingV1().Host(host)
+
+	return h
+}
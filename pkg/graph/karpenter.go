@@ -0,0 +1,121 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// KarpenterGroupVersion is the group version of the karpenter resources.
+var KarpenterGroupVersion = schema.GroupVersion{Group: "karpenter.sh", Version: "v1"}
+
+// KarpenterV1Graph is used to graph all karpenter resources.
+type KarpenterV1Graph struct {
+	graph *Graph
+}
+
+// NewKarpenterV1Graph creates a new KarpenterV1Graph.
+func NewKarpenterV1Graph(g *Graph) *KarpenterV1Graph {
+	return &KarpenterV1Graph{
+		graph: g,
+	}
+}
+
+// KarpenterV1 retrieves the KarpenterV1Graph.
+func (g *Graph) KarpenterV1() *KarpenterV1Graph {
+	return g.karpenterV1
+}
+
+// Unstructured adds an unstructured node to the Graph.
+// A NodeClaim is related to its NodePool by the owner references.
+func (g *KarpenterV1Graph) Unstructured(unstr *unstructured.Unstructured) (*Node, error) {
+	switch unstr.GetKind() {
+	case "NodePool":
+		return g.NodePool(unstr)
+	case "NodeClaim":
+		return g.NodeClaim(unstr)
+	default:
+		return g.graph.Node(unstr.GroupVersionKind(), unstr), nil
+	}
+}
+
+// NodePool adds a NodePool resource to the Graph, which is related to its NodeClass
+// and to all nodes labeled with the name of the NodePool.
+func (g *KarpenterV1Graph) NodePool(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	g.NodeClassRef(n, obj, "spec", "template", "spec", "nodeClassRef")
+
+	options := metav1.ListOptions{LabelSelector: "karpenter.sh/nodepool=" + obj.GetName()}
+	nodes, err := g.graph.CoreV1().NodeList(options)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range nodes.Items {
+		nodes.Items[i].SetGroupVersionKind(v1.SchemeGroupVersion.WithKind("Node"))
+		node, err := g.graph.CoreV1().Node(&nodes.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		g.graph.Relationship(n, "provisions", node)
+	}
+
+	return n, nil
+}
+
+// NodeClaim adds a NodeClaim resource to the Graph, which is related to its NodeClass and the launched node.
+func (g *KarpenterV1Graph) NodeClaim(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	g.NodeClassRef(n, obj, "spec", "nodeClassRef")
+
+	if name, _, _ := unstructured.NestedString(obj.Object, "status", "nodeName"); len(name) != 0 {
+		node := g.graph.Reference(v1.SchemeGroupVersion.WithKind("Node"), "", name)
+		g.graph.Relationship(n, "launched", node)
+	}
+
+	return n, nil
+}
+
+// NodeClassRef relates the node to the provider specific NodeClass like an EC2NodeClass referenced by the field.
+// The reference has a group since v1 and an apiVersion before.
+func (g *KarpenterV1Graph) NodeClassRef(n *Node, obj *unstructured.Unstructured, fields ...string) *Node {
+	ref, ok, _ := unstructured.NestedMap(obj.Object, fields...)
+	if !ok {
+		return nil
+	}
+
+	apiVersion, _, _ := unstructured.NestedString(ref, "apiVersion")
+	group, _, _ := unstructured.NestedString(ref, "group")
+	kind, _, _ := unstructured.NestedString(ref, "kind")
+	name, _, _ := unstructured.NestedString(ref, "name")
+	if len(kind) == 0 || len(name) == 0 {
+		return nil
+	}
+
+	gvk := schema.GroupVersionKind{Group: group, Kind: kind}
+	if len(apiVersion) != 0 {
+		gvk = schema.FromAPIVersionAndKind(apiVersion, kind)
+	}
+
+	r := g.graph.Reference(gvk, "", name)
+	g.graph.Relationship(n, "nodeClassRef", r)
+
+	return r
+}
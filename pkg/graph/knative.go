@@ -0,0 +1,120 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// KnativeServingGroupVersion is the group version of the knative serving resources.
+var KnativeServingGroupVersion = schema.GroupVersion{Group: "serving.knative.dev", Version: "v1"}
+
+// KnativeServingV1Graph is used to graph all knative serving resources.
+type KnativeServingV1Graph struct {
+	graph *Graph
+}
+
+// NewKnativeServingV1Graph creates a new KnativeServingV1Graph.
+func NewKnativeServingV1Graph(g *Graph) *KnativeServingV1Graph {
+	return &KnativeServingV1Graph{
+		graph: g,
+	}
+}
+
+// KnativeServingV1 retrieves the KnativeServingV1Graph.
+func (g *Graph) KnativeServingV1() *KnativeServingV1Graph {
+	return g.knativeServingV1
+}
+
+// Unstructured adds an unstructured node to the Graph.
+func (g *KnativeServingV1Graph) Unstructured(unstr *unstructured.Unstructured) (*Node, error) {
+	switch unstr.GetKind() {
+	case "Service":
+		return g.Service(unstr)
+	case "Configuration":
+		return g.Configuration(unstr)
+	case "Revision":
+		return g.Revision(unstr)
+	case "Route":
+		return g.Route(unstr)
+	default:
+		return g.graph.Node(unstr.GroupVersionKind(), unstr), nil
+	}
+}
+
+// Service adds a knative Service resource to the Graph.
+// The Configuration and Route of a service always have the same name as the service.
+func (g *KnativeServingV1Graph) Service(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	for _, kind := range []string{"Configuration", "Route"} {
+		r := g.graph.Reference(KnativeServingGroupVersion.WithKind(kind), obj.GetNamespace(), obj.GetName())
+		g.graph.Relationship(n, RelationshipOwns, r)
+	}
+
+	return n, nil
+}
+
+// Configuration adds a Configuration resource to the Graph, which is related to its latest created Revision.
+// Older revisions are related through their owner references.
+func (g *KnativeServingV1Graph) Configuration(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	if name, _, _ := unstructured.NestedString(obj.Object, "status", "latestCreatedRevisionName"); len(name) != 0 {
+		r := g.graph.Reference(KnativeServingGroupVersion.WithKind("Revision"), obj.GetNamespace(), name)
+		g.graph.Relationship(n, RelationshipOwns, r)
+	}
+
+	return n, nil
+}
+
+// Revision adds a Revision resource to the Graph, which is related to the Deployment running it.
+// The pods are related to the deployment through their owner references.
+func (g *KnativeServingV1Graph) Revision(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	d := g.graph.Reference(appsv1.SchemeGroupVersion.WithKind("Deployment"), obj.GetNamespace(), obj.GetName()+"-deployment")
+	g.graph.Relationship(n, RelationshipOwns, d)
+
+	return n, nil
+}
+
+// Route adds a Route resource to the Graph, which is related to each Revision receiving traffic.
+// During a rollout multiple revisions receive traffic, so each relationship is labeled with its percentage.
+func (g *KnativeServingV1Graph) Route(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	traffic, _, _ := unstructured.NestedSlice(obj.Object, "status", "traffic")
+	for _, target := range traffic {
+		t, ok := target.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(t, "revisionName")
+		if len(name) == 0 {
+			continue
+		}
+		percent, _, _ := unstructured.NestedInt64(t, "percent")
+
+		r := g.graph.Reference(KnativeServingGroupVersion.WithKind("Revision"), obj.GetNamespace(), name)
+		g.graph.Relationship(n, fmt.Sprintf("%d%%", percent), r)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,144 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	// KubeVirtGroupVersion is the group version of the kubevirt virtual machine resources.
+	KubeVirtGroupVersion = schema.GroupVersion{Group: "kubevirt.io", Version: "v1"}
+	// KubeVirtCDIGroupVersion is the group version of the kubevirt containerized data importer resources.
+	KubeVirtCDIGroupVersion = schema.GroupVersion{Group: "cdi.kubevirt.io", Version: "v1beta1"}
+)
+
+// KubeVirtV1Graph is used to graph all kubevirt resources.
+type KubeVirtV1Graph struct {
+	graph *Graph
+}
+
+// NewKubeVirtV1Graph creates a new KubeVirtV1Graph.
+func NewKubeVirtV1Graph(g *Graph) *KubeVirtV1Graph {
+	return &KubeVirtV1Graph{
+		graph: g,
+	}
+}
+
+// KubeVirtV1 retrieves the KubeVirtV1Graph.
+func (g *Graph) KubeVirtV1() *KubeVirtV1Graph {
+	return g.kubeVirtV1
+}
+
+// Unstructured adds an unstructured node to the Graph.
+func (g *KubeVirtV1Graph) Unstructured(unstr *unstructured.Unstructured) (*Node, error) {
+	switch unstr.GetKind() {
+	case "VirtualMachine":
+		return g.VirtualMachine(unstr)
+	case "VirtualMachineInstance":
+		return g.VirtualMachineInstance(unstr)
+	case "DataVolume":
+		return g.DataVolume(unstr)
+	default:
+		return g.graph.Node(unstr.GroupVersionKind(), unstr), nil
+	}
+}
+
+// VirtualMachine adds a VirtualMachine resource to the Graph.
+// A created virtual machine is related to its instance, which always has the same name.
+func (g *KubeVirtV1Graph) VirtualMachine(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	if created, _, _ := unstructured.NestedBool(obj.Object, "status", "created"); created {
+		i := g.graph.Reference(KubeVirtGroupVersion.WithKind("VirtualMachineInstance"), obj.GetNamespace(), obj.GetName())
+		g.graph.Relationship(n, RelationshipOwns, i)
+	}
+
+	templates, _, _ := unstructured.NestedSlice(obj.Object, "spec", "dataVolumeTemplates")
+	for _, template := range templates {
+		t, ok := template.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if name, _, _ := unstructured.NestedString(t, "metadata", "name"); len(name) != 0 {
+			d := g.graph.Reference(KubeVirtCDIGroupVersion.WithKind("DataVolume"), obj.GetNamespace(), name)
+			g.graph.Relationship(n, "dataVolumeTemplate", d)
+		}
+	}
+
+	volumes, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "volumes")
+	g.Volumes(n, obj.GetNamespace(), volumes)
+
+	return n, nil
+}
+
+// VirtualMachineInstance adds a VirtualMachineInstance resource to the Graph.
+// The virt-launcher pods of the instance are labeled with the UID of the instance.
+func (g *KubeVirtV1Graph) VirtualMachineInstance(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	volumes, _, _ := unstructured.NestedSlice(obj.Object, "spec", "volumes")
+	g.Volumes(n, obj.GetNamespace(), volumes)
+
+	selector := labels.SelectorFromSet(labels.Set{"kubevirt.io/created-by": string(obj.GetUID())})
+	options := metav1.ListOptions{LabelSelector: selector.String()}
+	pods, err := g.graph.CoreV1().PodList(obj.GetNamespace(), options)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range pods.Items {
+		p, err := g.graph.CoreV1().Pod(&pods.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		g.graph.Relationship(n, "virt-launcher", p)
+	}
+
+	return n, nil
+}
+
+// DataVolume adds a DataVolume resource to the Graph.
+// The persistent volume claim of a data volume always has the same name.
+func (g *KubeVirtV1Graph) DataVolume(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	c := g.graph.Reference(schema.FromAPIVersionAndKind(v1.GroupName, "PersistentVolumeClaim"), obj.GetNamespace(), obj.GetName())
+	g.graph.Relationship(n, "volume", c)
+
+	return n, nil
+}
+
+// Volumes adds the DataVolume and PersistentVolumeClaim resources referenced by volumes to the Graph.
+func (g *KubeVirtV1Graph) Volumes(n *Node, namespace string, volumes []interface{}) {
+	for _, volume := range volumes {
+		v, ok := volume.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if name, _, _ := unstructured.NestedString(v, "dataVolume", "name"); len(name) != 0 {
+			d := g.graph.Reference(KubeVirtCDIGroupVersion.WithKind("DataVolume"), namespace, name)
+			g.graph.Relationship(n, "volume", d)
+		}
+		if name, _, _ := unstructured.NestedString(v, "persistentVolumeClaim", "claimName"); len(name) != 0 {
+			c := g.graph.Reference(schema.FromAPIVersionAndKind(v1.GroupName, "PersistentVolumeClaim"), namespace, name)
+			g.graph.Relationship(n, "volume", c)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// KueueGroupVersion is the group version of the kueue resources.
+var KueueGroupVersion = schema.GroupVersion{Group: "kueue.x-k8s.io", Version: "v1beta1"}
+
+// KueueV1Graph is used to graph all kueue resources.
+type KueueV1Graph struct {
+	graph *Graph
+}
+
+// NewKueueV1Graph creates a new KueueV1Graph.
+func NewKueueV1Graph(g *Graph) *KueueV1Graph {
+	return &KueueV1Graph{
+		graph: g,
+	}
+}
+
+// KueueV1 retrieves the KueueV1Graph.
+func (g *Graph) KueueV1() *KueueV1Graph {
+	return g.kueueV1
+}
+
+// Unstructured adds an unstructured node to the Graph.
+// A Workload is related to the Job or JobSet it admits by the owner references.
+func (g *KueueV1Graph) Unstructured(unstr *unstructured.Unstructured) (*Node, error) {
+	switch unstr.GetKind() {
+	case "Workload":
+		return g.Workload(unstr)
+	case "LocalQueue":
+		return g.LocalQueue(unstr)
+	case "ClusterQueue":
+		return g.ClusterQueue(unstr)
+	default:
+		return g.graph.Node(unstr.GroupVersionKind(), unstr), nil
+	}
+}
+
+// Workload adds a Workload resource to the Graph, which is related to its LocalQueue and,
+// once it is admitted, to the ClusterQueue and the ResourceFlavors assigned to its pod sets.
+// A Workload which is pending admission is only related to its LocalQueue.
+func (g *KueueV1Graph) Workload(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	if name, _, _ := unstructured.NestedString(obj.Object, "spec", "queueName"); len(name) != 0 {
+		q := g.graph.Reference(KueueGroupVersion.WithKind("LocalQueue"), obj.GetNamespace(), name)
+		g.graph.Relationship(n, "queue", q)
+	}
+
+	if name, _, _ := unstructured.NestedString(obj.Object, "status", "admission", "clusterQueue"); len(name) != 0 {
+		q := g.graph.Reference(KueueGroupVersion.WithKind("ClusterQueue"), "", name)
+		g.graph.Relationship(n, "admittedBy", q)
+	}
+
+	assignments, _, _ := unstructured.NestedSlice(obj.Object, "status", "admission", "podSetAssignments")
+	for _, assignment := range assignments {
+		assignment, ok := assignment.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		flavors, _, _ := unstructured.NestedStringMap(assignment, "flavors")
+		for _, name := range flavors {
+			f := g.graph.Reference(KueueGroupVersion.WithKind("ResourceFlavor"), "", name)
+			g.graph.Relationship(n, "flavor", f)
+		}
+	}
+
+	return n, nil
+}
+
+// LocalQueue adds a LocalQueue resource to the Graph, which is related to its ClusterQueue.
+func (g *KueueV1Graph) LocalQueue(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	if name, _, _ := unstructured.NestedString(obj.Object, "spec", "clusterQueue"); len(name) != 0 {
+		q := g.graph.Reference(KueueGroupVersion.WithKind("ClusterQueue"), "", name)
+		g.graph.Relationship(n, "clusterQueue", q)
+	}
+
+	return n, nil
+}
+
+// ClusterQueue adds a ClusterQueue resource to the Graph, which is related to the ResourceFlavors of its resource groups.
+func (g *KueueV1Graph) ClusterQueue(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	groups, _, _ := unstructured.NestedSlice(obj.Object, "spec", "resourceGroups")
+	for _, group := range groups {
+		group, ok := group.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		flavors, _, _ := unstructured.NestedSlice(group, "flavors")
+		for _, flavor := range flavors {
+			flavor, ok := flavor.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			if name, _, _ := unstructured.NestedString(flavor, "name"); len(name) != 0 {
+				f := g.graph.Reference(KueueGroupVersion.WithKind("ResourceFlavor"), "", name)
+				g.graph.Relationship(n, "flavor", f)
+			}
+		}
+	}
+
+	return n, nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// MetricsGroupVersion is the group version of the metrics-server resources.
+var MetricsGroupVersion = schema.GroupVersion{Group: "metrics.k8s.io", Version: "v1beta1"}
+
+// PodMetrics adds the current cpu and memory usage of the metrics-server to all pod nodes of the Graph.
+// The usage of all containers of a pod is summed up and the pods are joined by namespace and name.
+// The metrics are listed until the context is done.
+func (g *Graph) PodMetrics(ctx context.Context) error {
+	g.ctx = ctx
+	defer func() { g.ctx = context.Background() }()
+
+	pods := make(map[string]map[string]*Node)
+	for _, node := range g.Nodes {
+		if gvk := node.GroupVersionKind(); len(gvk.Group) != 0 || gvk.Kind != "Pod" {
+			continue
+		}
+		if pods[node.Namespace] == nil {
+			pods[node.Namespace] = make(map[string]*Node)
+		}
+		pods[node.Namespace][node.Name] = node
+	}
+
+	namespaces := make([]string, 0, len(pods))
+	for namespace := range pods {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	for _, namespace := range namespaces {
+		list, err := g.List(MetricsGroupVersion, namespace, "pods", metav1.ListOptions{})
+		if err != nil {
+			return err
+		}
+
+		for _, item := range list.Items {
+			node, ok := pods[namespace][item.GetName()]
+			if !ok {
+				continue
+			}
+
+			usage := v1.ResourceList{}
+			containers, _, _ := unstructured.NestedSlice(item.Object, "containers")
+			for _, container := range containers {
+				c, ok := container.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				resources, _, _ := unstructured.NestedStringMap(c, "usage")
+				for name, value := range resources {
+					quantity, err := resource.ParseQuantity(value)
+					if err != nil {
+						continue
+					}
+					total := usage[v1.ResourceName(name)]
+					total.Add(quantity)
+					usage[v1.ResourceName(name)] = total
+				}
+			}
+			node.usage = usage
+		}
+	}
+
+	return nil
+}
+
+// Usage returns the current cpu and memory usage of the node like "cpu: 250m, memory: 128Mi".
+// An empty string is returned if the usage is unknown.
+func (n *Node) Usage() string {
+	cpu, hasCPU := n.usage[v1.ResourceCPU]
+	memory, hasMemory := n.usage[v1.ResourceMemory]
+
+	switch {
+	case hasCPU && hasMemory:
+		return fmt.Sprintf("cpu: %dm, memory: %dMi", cpu.MilliValue(), memory.Value()/(1024*1024))
+	case hasCPU:
+		return fmt.Sprintf("cpu: %dm", cpu.MilliValue())
+	case hasMemory:
+		return fmt.Sprintf("memory: %dMi", memory.Value()/(1024*1024))
+	}
+
+	return ""
+}
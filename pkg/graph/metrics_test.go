@@ -0,0 +1,47 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"context"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodMetricsAddsUsageToPods(t *testing.T) {
+	g := newTestGraph(t, map[string]string{
+		"/apis/metrics.k8s.io/v1beta1/namespaces/default/pods": `{"kind": "PodMetricsList", "apiVersion": "metrics.k8s.io/v1beta1", "items": [
+			{"kind": "PodMetrics", "apiVersion": "metrics.k8s.io/v1beta1", "metadata": {"name": "web-1", "namespace": "default"}, "containers": [
+				{"name": "web", "usage": {"cpu": "200m", "memory": "64Mi"}},
+				{"name": "sidecar", "usage": {"cpu": "50m", "memory": "64Mi"}}
+			]}
+		]}`,
+	})
+
+	n, err := g.CoreV1().Pod(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default", UID: "pod"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := g.PodMetrics(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if usage := n.Usage(); usage != "cpu: 250m, memory: 128Mi" {
+		t.Errorf("expected the summed usage of the containers, got %q", usage)
+	}
+}
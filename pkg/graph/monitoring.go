@@ -0,0 +1,191 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// MonitoringGroupVersion is the group version of the prometheus operator resources.
+var MonitoringGroupVersion = schema.GroupVersion{Group: "monitoring.coreos.com", Version: "v1"}
+
+// MonitoringV1Graph is used to graph all prometheus operator resources.
+type MonitoringV1Graph struct {
+	graph *Graph
+}
+
+// NewMonitoringV1Graph creates a new MonitoringV1Graph.
+func NewMonitoringV1Graph(g *Graph) *MonitoringV1Graph {
+	return &MonitoringV1Graph{
+		graph: g,
+	}
+}
+
+// MonitoringV1 retrieves the MonitoringV1Graph.
+func (g *Graph) MonitoringV1() *MonitoringV1Graph {
+	return g.monitoringV1
+}
+
+// Unstructured adds an unstructured node to the Graph.
+func (g *MonitoringV1Graph) Unstructured(unstr *unstructured.Unstructured) (*Node, error) {
+	switch unstr.GetKind() {
+	case "Prometheus":
+		return g.Prometheus(unstr)
+	case "ServiceMonitor":
+		return g.ServiceMonitor(unstr)
+	default:
+		return g.graph.Node(unstr.GroupVersionKind(), unstr), nil
+	}
+}
+
+// Prometheus adds a Prometheus resource to the Graph, which is related to the selected ServiceMonitor and PodMonitor resources.
+// Like the prometheus operator, a nil selector selects no monitors and an empty selector selects all monitors.
+// A nil namespace selector selects the namespace of the Prometheus only, an empty one selects all namespaces.
+func (g *MonitoringV1Graph) Prometheus(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	monitors := []struct {
+		resource string
+		field    string
+	}{
+		{"servicemonitors", "serviceMonitor"},
+		{"podmonitors", "podMonitor"},
+	}
+
+	for _, monitor := range monitors {
+		selector, err := g.LabelSelector(obj, monitor.field+"Selector")
+		if err != nil {
+			return nil, err
+		}
+		if selector == nil {
+			continue
+		}
+
+		namespaces, err := g.Namespaces(obj, monitor.field+"NamespaceSelector")
+		if err != nil {
+			return nil, err
+		}
+
+		for _, namespace := range namespaces {
+			options := metav1.ListOptions{LabelSelector: selector.String()}
+			list, err := g.List(namespace, monitor.resource, options)
+			if err != nil {
+				return nil, err
+			}
+
+			for i := range list.Items {
+				m, err := g.Unstructured(&list.Items[i])
+				if err != nil {
+					return nil, err
+				}
+				g.graph.Relationship(n, RelationshipSelects, m)
+			}
+		}
+	}
+
+	return n, nil
+}
+
+// ServiceMonitor adds a ServiceMonitor resource to the Graph, which is related to the scraped services.
+// An empty selector selects all services within the namespaces of the namespace selector.
+func (g *MonitoringV1Graph) ServiceMonitor(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	selector, err := g.LabelSelector(obj, "selector")
+	if err != nil {
+		return nil, err
+	}
+	if selector == nil {
+		return n, nil
+	}
+
+	namespaces := []string{obj.GetNamespace()}
+	if all, _, _ := unstructured.NestedBool(obj.Object, "spec", "namespaceSelector", "any"); all {
+		namespaces = []string{metav1.NamespaceAll}
+	} else if names, ok, _ := unstructured.NestedStringSlice(obj.Object, "spec", "namespaceSelector", "matchNames"); ok && len(names) != 0 {
+		namespaces = names
+	}
+
+	for _, namespace := range namespaces {
+		options := metav1.ListOptions{LabelSelector: selector.String()}
+		services, err := g.graph.CoreV1().ServiceList(namespace, options)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range services.Items {
+			s := g.graph.Node(schema.FromAPIVersionAndKind(v1.GroupName, "Service"), &services.Items[i])
+			g.graph.Relationship(n, "scrapes", s)
+		}
+	}
+
+	return n, nil
+}
+
+// LabelSelector returns the label selector of a field within spec or nil if the field is not set.
+func (g *MonitoringV1Graph) LabelSelector(obj *unstructured.Unstructured, field string) (labels.Selector, error) {
+	m, ok, _ := unstructured.NestedMap(obj.Object, "spec", field)
+	if !ok {
+		return nil, nil
+	}
+
+	selector := &metav1.LabelSelector{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, selector); err != nil {
+		return nil, fmt.Errorf("failed to convert %s of %s/%s: %v", field, obj.GetNamespace(), obj.GetName(), err)
+	}
+
+	return metav1.LabelSelectorAsSelector(selector)
+}
+
+// Namespaces returns the namespaces selected by a namespace selector field within spec.
+func (g *MonitoringV1Graph) Namespaces(obj *unstructured.Unstructured, field string) ([]string, error) {
+	selector, err := g.LabelSelector(obj, field)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case selector == nil:
+		return []string{obj.GetNamespace()}, nil
+	case selector.Empty():
+		return []string{metav1.NamespaceAll}, nil
+	}
+
+	options := metav1.ListOptions{LabelSelector: selector.String()}
+	list, err := g.graph.CoreV1().NamespaceList(options)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces := []string{}
+	for _, namespace := range list.Items {
+		namespaces = append(namespaces, namespace.GetName())
+	}
+
+	return namespaces, nil
+}
+
+// List lists all prometheus operator resources within a namespace in chunks of the configured size.
+// An empty list is returned if the current user is not allowed to list the resources.
+func (g *MonitoringV1Graph) List(namespace string, resource string, options metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	return g.graph.List(MonitoringGroupVersion, namespace, resource, options)
+}
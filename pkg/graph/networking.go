@@ -15,10 +15,10 @@
 package graph
 
 import (
-	"context"
 	"fmt"
 
 	v1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -61,32 +61,42 @@ func (g *NetworkingV1Graph) Unstructured(unstr *unstructured.Unstructured) (*Nod
 	}
 }
 
-// Relationship creates a new relationship between two nodes based on v1.PolicyType.
-func (g *NetworkingV1Graph) Relationship(from *Node, policyType v1.PolicyType, to *Node) (r *Relationship) {
+// Relationship creates a new labeled relationship between two nodes based on v1.PolicyType.
+func (g *NetworkingV1Graph) Relationship(from *Node, policyType v1.PolicyType, label string, to *Node) (r *Relationship) {
 	switch policyType {
 	case v1.PolicyTypeIngress:
-		r = g.graph.Relationship(to, string(policyType), from)
+		r = g.graph.Relationship(to, label, from)
 		r.Attribute("color", "#34a853")
 	case v1.PolicyTypeEgress:
-		r = g.graph.Relationship(from, string(policyType), to)
+		r = g.graph.Relationship(from, label, to)
 		r.Attribute("color", "#ea4335")
 	}
 
 	return r.Attribute("style", "dashed")
 }
 
+// peerLabel returns the relationship label for a network policy peer based on v1.PolicyType.
+func peerLabel(policyType v1.PolicyType) string {
+	if policyType == v1.PolicyTypeEgress {
+		return "egress-to"
+	}
+
+	return "ingress-from"
+}
+
 // Ingress adds a v1.Ingress resource to the Graph.
 func (g *NetworkingV1Graph) Ingress(obj *v1.Ingress) (*Node, error) {
 	n := g.graph.Node(obj.GroupVersionKind(), obj)
 
+	backends := []v1.IngressBackend{}
+	if obj.Spec.DefaultBackend != nil {
+		backends = append(backends, *obj.Spec.DefaultBackend)
+	}
+
 	for _, rule := range obj.Spec.Rules {
 		if rule.HTTP != nil {
 			for _, path := range rule.HTTP.Paths {
-				b, err := g.IngressBackend(obj, path.Backend)
-				if err != nil {
-					return nil, err
-				}
-				g.Relationship(b, v1.PolicyTypeIngress, n)
+				backends = append(backends, path.Backend)
 			}
 		}
 
@@ -94,18 +104,32 @@ func (g *NetworkingV1Graph) Ingress(obj *v1.Ingress) (*Node, error) {
 		if err != nil {
 			return nil, err
 		}
-		g.Relationship(n, v1.PolicyTypeIngress, h)
+		g.Relationship(n, v1.PolicyTypeIngress, string(v1.PolicyTypeIngress), h)
+	}
+
+	for _, backend := range backends {
+		b, err := g.IngressBackend(obj, backend)
+		if err != nil {
+			return nil, err
+		}
+		if b != nil {
+			g.Relationship(b, v1.PolicyTypeIngress, "backend", n)
+		}
 	}
 
 	return n, nil
 }
 
 // IngressBackend adds a v1.IngressBackend resource to the Graph.
+// A backend service which does not exist is skipped and no node is returned.
 func (g *NetworkingV1Graph) IngressBackend(obj *v1.Ingress, backend v1.IngressBackend) (*Node, error) {
 	switch {
 	case backend.Service != nil:
 		options := metav1.GetOptions{}
-		service, err := g.graph.clientset.CoreV1().Services(obj.GetNamespace()).Get(context.TODO(), backend.Service.Name, options)
+		service, err := g.graph.clientset.CoreV1().Services(obj.GetNamespace()).Get(g.graph.ctx, backend.Service.Name, options)
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -132,6 +156,7 @@ func (g *NetworkingV1Graph) Host(name string) (*Node, error) {
 }
 
 // NetworkPolicy adds a v1.NetworkPolicy resource to the Graph.
+// An empty pod selector selects all pods within the namespace of the policy.
 func (g *NetworkingV1Graph) NetworkPolicy(obj *v1.NetworkPolicy) (*Node, error) {
 	n := g.graph.Node(obj.GroupVersionKind(), obj)
 
@@ -141,22 +166,17 @@ func (g *NetworkingV1Graph) NetworkPolicy(obj *v1.NetworkPolicy) (*Node, error)
 	}
 
 	options := metav1.ListOptions{LabelSelector: selector.String(), FieldSelector: "status.phase=Running"}
-	pods, err := g.graph.clientset.CoreV1().Pods(obj.GetNamespace()).List(context.TODO(), options)
+	pods, err := g.graph.CoreV1().PodList(obj.GetNamespace(), options)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, pod := range pods.Items {
-		p, err := g.graph.CoreV1().Pod(&pod)
+	for i := range pods.Items {
+		p, err := g.graph.CoreV1().Pod(&pods.Items[i])
 		if err != nil {
 			return nil, err
 		}
-		if len(obj.Spec.Ingress) != 0 {
-			g.Relationship(p, v1.PolicyTypeIngress, n)
-		}
-		if len(obj.Spec.Egress) != 0 {
-			g.Relationship(p, v1.PolicyTypeEgress, n)
-		}
+		g.graph.Relationship(n, "applies-to", p).Attribute("style", "dashed")
 	}
 
 	for _, rule := range obj.Spec.Ingress {
@@ -212,7 +232,7 @@ func (g *NetworkingV1Graph) NetworkPolicyPeerNamespaceAndPodSelector(obj *v1.Net
 	}
 
 	options := metav1.ListOptions{LabelSelector: selector.String()}
-	namespaces, err := g.graph.clientset.CoreV1().Namespaces().List(context.TODO(), options)
+	namespaces, err := g.graph.CoreV1().NamespaceList(options)
 	if err != nil {
 		return nil, err
 	}
@@ -224,17 +244,17 @@ func (g *NetworkingV1Graph) NetworkPolicyPeerNamespaceAndPodSelector(obj *v1.Net
 		}
 
 		options := metav1.ListOptions{LabelSelector: selector.String(), FieldSelector: "status.phase=Running"}
-		pods, err := g.graph.clientset.CoreV1().Pods(namespace.GetName()).List(context.TODO(), options)
+		pods, err := g.graph.CoreV1().PodList(namespace.GetName(), options)
 		if err != nil {
 			return nil, err
 		}
 
-		for _, pod := range pods.Items {
-			p, err := g.graph.CoreV1().Pod(&pod)
+		for i := range pods.Items {
+			p, err := g.graph.CoreV1().Pod(&pods.Items[i])
 			if err != nil {
 				return nil, err
 			}
-			g.Relationship(n, policyType, p)
+			g.Relationship(n, policyType, peerLabel(policyType), p)
 		}
 	}
 
@@ -251,17 +271,17 @@ func (g *NetworkingV1Graph) NetworkPolicyPeerNamespaceSelector(obj *v1.NetworkPo
 	}
 
 	options := metav1.ListOptions{LabelSelector: selector.String()}
-	namespaces, err := g.graph.clientset.CoreV1().Namespaces().List(context.TODO(), options)
+	namespaces, err := g.graph.CoreV1().NamespaceList(options)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, namespace := range namespaces.Items {
-		ns, err := g.graph.CoreV1().Namespace(&namespace)
+	for i := range namespaces.Items {
+		ns, err := g.graph.CoreV1().Namespace(&namespaces.Items[i])
 		if err != nil {
 			return nil, err
 		}
-		g.Relationship(n, policyType, ns)
+		g.Relationship(n, policyType, peerLabel(policyType), ns)
 	}
 
 	return n, nil
@@ -277,17 +297,17 @@ func (g *NetworkingV1Graph) NetworkPolicyPeerPodSelector(obj *v1.NetworkPolicy,
 	}
 
 	options := metav1.ListOptions{LabelSelector: selector.String(), FieldSelector: "status.phase=Running"}
-	pods, err := g.graph.clientset.CoreV1().Pods(obj.GetNamespace()).List(context.TODO(), options)
+	pods, err := g.graph.CoreV1().PodList(obj.GetNamespace(), options)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, pod := range pods.Items {
-		p, err := g.graph.CoreV1().Pod(&pod)
+	for i := range pods.Items {
+		p, err := g.graph.CoreV1().Pod(&pods.Items[i])
 		if err != nil {
 			return nil, err
 		}
-		g.Relationship(n, policyType, p)
+		g.Relationship(n, policyType, peerLabel(policyType), p)
 	}
 
 	return n, nil
@@ -301,7 +321,7 @@ func (g *NetworkingV1Graph) NetworkPolicyPeerIPBlock(obj *v1.NetworkPolicy, poli
 	if err != nil {
 		return nil, err
 	}
-	g.Relationship(n, policyType, i)
+	g.Relationship(n, policyType, peerLabel(policyType), i)
 
 	return n, nil
 }
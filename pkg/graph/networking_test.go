@@ -0,0 +1,57 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNetworkPolicyRelatesDistinctPods(t *testing.T) {
+	g := newTestGraph(t, map[string]string{
+		"/api/v1/namespaces/default/pods": `{"kind": "PodList", "apiVersion": "v1", "items": [
+			{"metadata": {"name": "web-1", "namespace": "default", "uid": "pod-1"}},
+			{"metadata": {"name": "web-2", "namespace": "default", "uid": "pod-2"}},
+			{"metadata": {"name": "web-3", "namespace": "default", "uid": "pod-3"}}
+		]}`,
+	})
+
+	obj := &v1.NetworkPolicy{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "networking.k8s.io/v1", Kind: "NetworkPolicy"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "policy"},
+	}
+	n, err := g.NetworkingV1().NetworkPolicy(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pods := map[string]bool{}
+	for _, r := range g.RelationshipList() {
+		if r.From == n.UID && r.Label == "applies-to" {
+			pods[g.Nodes[r.To].Name] = true
+		}
+	}
+
+	for _, name := range []string{"web-1", "web-2", "web-3"} {
+		if !pods[name] {
+			t.Errorf("expected the policy to apply to pod %q, got %v", name, pods)
+		}
+	}
+	if len(pods) != 3 {
+		t.Errorf("expected 3 distinct pods, got %d", len(pods))
+	}
+}
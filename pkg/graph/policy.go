@@ -0,0 +1,84 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	v1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// PolicyV1Graph is used to graph all policy resources.
+type PolicyV1Graph struct {
+	graph *Graph
+}
+
+// NewPolicyV1Graph creates a new PolicyV1Graph.
+func NewPolicyV1Graph(g *Graph) *PolicyV1Graph {
+	return &PolicyV1Graph{
+		graph: g,
+	}
+}
+
+// PolicyV1 retrieves the PolicyV1Graph.
+func (g *Graph) PolicyV1() *PolicyV1Graph {
+	return g.policyV1
+}
+
+// Unstructured adds an unstructured node to the Graph.
+func (g *PolicyV1Graph) Unstructured(unstr *unstructured.Unstructured) (*Node, error) {
+	switch unstr.GetKind() {
+	case "PodDisruptionBudget":
+		obj := &v1.PodDisruptionBudget{}
+		if err := FromUnstructured(unstr, obj); err != nil {
+			return nil, err
+		}
+		return g.PodDisruptionBudget(obj)
+	default:
+		return g.graph.Node(unstr.GroupVersionKind(), unstr), nil
+	}
+}
+
+// PodDisruptionBudget adds a v1.PodDisruptionBudget resource to the Graph.
+// A nil selector selects no pods, while an empty selector selects all pods within the namespace of the budget.
+// The workloads of the selected pods are related through the owner references of the pods.
+func (g *PolicyV1Graph) PodDisruptionBudget(obj *v1.PodDisruptionBudget) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	if obj.Spec.Selector == nil {
+		return n, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(obj.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+
+	options := metav1.ListOptions{LabelSelector: selector.String()}
+	pods, err := g.graph.CoreV1().PodList(obj.GetNamespace(), options)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range pods.Items {
+		p, err := g.graph.CoreV1().Pod(&pods.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		g.graph.Relationship(n, "protects", p)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// KyvernoGroupVersion is the group version of the kyverno policy resources.
+var KyvernoGroupVersion = schema.GroupVersion{Group: "kyverno.io", Version: "v1"}
+
+// PolicyReportV1Graph is used to graph all policy report resources.
+type PolicyReportV1Graph struct {
+	graph *Graph
+}
+
+// NewPolicyReportV1Graph creates a new PolicyReportV1Graph.
+func NewPolicyReportV1Graph(g *Graph) *PolicyReportV1Graph {
+	return &PolicyReportV1Graph{
+		graph: g,
+	}
+}
+
+// PolicyReportV1 retrieves the PolicyReportV1Graph.
+func (g *Graph) PolicyReportV1() *PolicyReportV1Graph {
+	return g.policyReportV1
+}
+
+// Unstructured adds an unstructured node to the Graph.
+func (g *PolicyReportV1Graph) Unstructured(unstr *unstructured.Unstructured) (*Node, error) {
+	switch unstr.GetKind() {
+	case "PolicyReport", "ClusterPolicyReport":
+		return g.PolicyReport(unstr)
+	default:
+		return g.graph.Node(unstr.GroupVersionKind(), unstr), nil
+	}
+}
+
+// PolicyReport adds a PolicyReport or ClusterPolicyReport resource to the Graph.
+// The report is related to the policy of each result and to the resources of the result, labeled with the result.
+// Results without resources apply to the scope of the report. Failed results are colored red.
+func (g *PolicyReportV1Graph) PolicyReport(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	scope, _, _ := unstructured.NestedMap(obj.Object, "scope")
+
+	results, _, _ := unstructured.NestedSlice(obj.Object, "results")
+	for _, result := range results {
+		r, ok := result.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		if name, _, _ := unstructured.NestedString(r, "policy"); len(name) != 0 {
+			p := g.Policy(name)
+			g.graph.Relationship(n, "policy", p)
+		}
+
+		resources, _, _ := unstructured.NestedSlice(r, "resources")
+		if len(resources) == 0 && scope != nil {
+			resources = []interface{}{scope}
+		}
+
+		label, _, _ := unstructured.NestedString(r, "result")
+		for _, resource := range resources {
+			ref, ok := resource.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			t := g.ObjectReference(ref, obj.GetNamespace())
+			if t == nil {
+				continue
+			}
+
+			relationship := g.graph.Relationship(n, label, t)
+			if label == "fail" || label == "error" {
+				relationship.Attribute("color", "#ea4335")
+			}
+		}
+	}
+
+	return n, nil
+}
+
+// Policy adds the kyverno policy of a result to the Graph.
+// Results of a namespaced Policy are named namespace/name, otherwise the result belongs to a ClusterPolicy.
+func (g *PolicyReportV1Graph) Policy(name string) *Node {
+	if namespace, name, ok := strings.Cut(name, "/"); ok {
+		return g.graph.Reference(KyvernoGroupVersion.WithKind("Policy"), namespace, name)
+	}
+
+	return g.graph.Reference(KyvernoGroupVersion.WithKind("ClusterPolicy"), "", name)
+}
+
+// ObjectReference adds the resource referenced by a result to the Graph.
+// A reference without a namespace is within the namespace of a PolicyReport or cluster-scoped for a ClusterPolicyReport.
+func (g *PolicyReportV1Graph) ObjectReference(ref map[string]interface{}, namespace string) *Node {
+	apiVersion, _, _ := unstructured.NestedString(ref, "apiVersion")
+	kind, _, _ := unstructured.NestedString(ref, "kind")
+	name, _, _ := unstructured.NestedString(ref, "name")
+	if len(kind) == 0 || len(name) == 0 {
+		return nil
+	}
+	if ns, _, _ := unstructured.NestedString(ref, "namespace"); len(ns) != 0 {
+		namespace = ns
+	}
+
+	return g.graph.Reference(schema.FromAPIVersionAndKind(apiVersion, kind), namespace, name)
+}
@@ -0,0 +1,131 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// RbacV1Graph is used to graph all rbac resources.
+type RbacV1Graph struct {
+	graph *Graph
+}
+
+// NewRbacV1Graph creates a new RbacV1Graph.
+func NewRbacV1Graph(g *Graph) *RbacV1Graph {
+	return &RbacV1Graph{
+		graph: g,
+	}
+}
+
+// RbacV1 retrieves the RbacV1Graph.
+func (g *Graph) RbacV1() *RbacV1Graph {
+	return g.rbacV1
+}
+
+// Unstructured adds an unstructured node to the Graph.
+func (g *RbacV1Graph) Unstructured(unstr *unstructured.Unstructured) (*Node, error) {
+	switch unstr.GetKind() {
+	case "RoleBinding":
+		obj := &v1.RoleBinding{}
+		if err := FromUnstructured(unstr, obj); err != nil {
+			return nil, err
+		}
+		return g.RoleBinding(obj)
+	case "ClusterRoleBinding":
+		obj := &v1.ClusterRoleBinding{}
+		if err := FromUnstructured(unstr, obj); err != nil {
+			return nil, err
+		}
+		return g.ClusterRoleBinding(obj)
+	default:
+		return g.graph.Node(unstr.GroupVersionKind(), unstr), nil
+	}
+}
+
+// RoleBinding adds a v1.RoleBinding resource to the Graph.
+func (g *RbacV1Graph) RoleBinding(obj *v1.RoleBinding) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	if _, err := g.RoleRef(n, obj.RoleRef, obj.GetNamespace()); err != nil {
+		return nil, err
+	}
+
+	for _, subject := range obj.Subjects {
+		if _, err := g.Subject(n, subject, obj.GetNamespace()); err != nil {
+			return nil, err
+		}
+	}
+
+	return n, nil
+}
+
+// ClusterRoleBinding adds a v1.ClusterRoleBinding resource to the Graph.
+func (g *RbacV1Graph) ClusterRoleBinding(obj *v1.ClusterRoleBinding) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	if _, err := g.RoleRef(n, obj.RoleRef, ""); err != nil {
+		return nil, err
+	}
+
+	for _, subject := range obj.Subjects {
+		if _, err := g.Subject(n, subject, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	return n, nil
+}
+
+// RoleRef adds the v1.Role or v1.ClusterRole referenced by a binding to the Graph.
+func (g *RbacV1Graph) RoleRef(binding *Node, ref v1.RoleRef, namespace string) (*Node, error) {
+	if ref.Kind == "ClusterRole" {
+		namespace = ""
+	}
+
+	n := g.graph.Reference(v1.SchemeGroupVersion.WithKind(ref.Kind), namespace, ref.Name)
+	g.graph.Relationship(binding, "roleRef", n)
+
+	return n, nil
+}
+
+// Subject adds a v1.Subject of a binding to the Graph.
+// Users and groups are not backed by any resource and are added as synthetic nodes.
+func (g *RbacV1Graph) Subject(binding *Node, subject v1.Subject, namespace string) (*Node, error) {
+	var n *Node
+
+	switch subject.Kind {
+	case v1.ServiceAccountKind:
+		if len(subject.Namespace) != 0 {
+			namespace = subject.Namespace
+		}
+		n = g.graph.Reference(schema.FromAPIVersionAndKind(corev1.GroupName, subject.Kind), namespace, subject.Name)
+	default:
+		n = g.graph.Node(
+			v1.SchemeGroupVersion.WithKind(subject.Kind),
+			&metav1.ObjectMeta{
+				UID:  ToUID(subject.Kind, subject.Name),
+				Name: subject.Name,
+			},
+		)
+	}
+	g.graph.Relationship(binding, "subject", n)
+
+	return n, nil
+}
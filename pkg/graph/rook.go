@@ -0,0 +1,177 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// RookCephGroupVersion is the group version of the rook ceph resources.
+var RookCephGroupVersion = schema.GroupVersion{Group: "ceph.rook.io", Version: "v1"}
+
+// RookCephV1Graph is used to graph all rook ceph resources.
+type RookCephV1Graph struct {
+	graph *Graph
+}
+
+// NewRookCephV1Graph creates a new RookCephV1Graph.
+func NewRookCephV1Graph(g *Graph) *RookCephV1Graph {
+	return &RookCephV1Graph{
+		graph: g,
+	}
+}
+
+// RookCephV1 retrieves the RookCephV1Graph.
+func (g *Graph) RookCephV1() *RookCephV1Graph {
+	return g.rookCephV1
+}
+
+// Unstructured adds an unstructured node to the Graph.
+func (g *RookCephV1Graph) Unstructured(unstr *unstructured.Unstructured) (*Node, error) {
+	switch unstr.GetKind() {
+	case "CephCluster":
+		return g.CephCluster(unstr)
+	case "CephBlockPool":
+		return g.CephBlockPool(unstr)
+	case "CephFilesystem":
+		return g.CephFilesystem(unstr)
+	case "CephObjectStore":
+		return g.CephObjectStore(unstr)
+	default:
+		return g.graph.Node(unstr.GroupVersionKind(), unstr), nil
+	}
+}
+
+// CephCluster adds a CephCluster resource to the Graph, which is related to the pools, filesystems and object stores
+// within its namespace, to the OSD pods of the cluster and to the rook operator pods.
+func (g *RookCephV1Graph) CephCluster(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	for _, resource := range []string{"cephblockpools", "cephfilesystems", "cephobjectstores"} {
+		list, err := g.graph.List(RookCephGroupVersion, obj.GetNamespace(), resource, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range list.Items {
+			c, err := g.Unstructured(&list.Items[i])
+			if err != nil {
+				return nil, err
+			}
+			g.graph.Relationship(n, list.Items[i].GetKind(), c)
+		}
+	}
+
+	options := metav1.ListOptions{LabelSelector: fmt.Sprintf("app=rook-ceph-osd,rook_cluster=%s", obj.GetNamespace())}
+	osds, err := g.graph.CoreV1().PodList(obj.GetNamespace(), options)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range osds.Items {
+		p, err := g.graph.CoreV1().Pod(&osds.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		g.graph.Relationship(n, "osd", p)
+	}
+
+	// The operator may run in any namespace, so its pods are searched across all namespaces.
+	options = metav1.ListOptions{LabelSelector: "app=rook-ceph-operator"}
+	operators, err := g.graph.CoreV1().PodList(metav1.NamespaceAll, options)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range operators.Items {
+		p, err := g.graph.CoreV1().Pod(&operators.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		g.graph.Relationship(p, "manages", n)
+	}
+
+	return n, nil
+}
+
+// CephBlockPool adds a CephBlockPool resource to the Graph, which is related to the StorageClasses of the RBD driver
+// with the namespace of the pool as clusterID and the name of the pool as pool.
+func (g *RookCephV1Graph) CephBlockPool(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	err := g.StorageClasses(n, map[string]string{"clusterID": obj.GetNamespace(), "pool": obj.GetName()})
+	if err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+// CephFilesystem adds a CephFilesystem resource to the Graph, which is related to the StorageClasses of the CephFS driver
+// with the namespace of the filesystem as clusterID and the name of the filesystem as fsName.
+func (g *RookCephV1Graph) CephFilesystem(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	err := g.StorageClasses(n, map[string]string{"clusterID": obj.GetNamespace(), "fsName": obj.GetName()})
+	if err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+// CephObjectStore adds a CephObjectStore resource to the Graph, which is related to the StorageClasses of the bucket
+// provisioner with the name and namespace of the object store as objectStoreName and objectStoreNamespace.
+func (g *RookCephV1Graph) CephObjectStore(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	err := g.StorageClasses(n, map[string]string{"objectStoreName": obj.GetName(), "objectStoreNamespace": obj.GetNamespace()})
+	if err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+// StorageClasses relates all StorageClasses to the node, whose parameters contain all of the given parameters.
+func (g *RookCephV1Graph) StorageClasses(n *Node, parameters map[string]string) error {
+	list, err := g.graph.List(StorageGroupVersion, metav1.NamespaceAll, "storageclasses", metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	for i := range list.Items {
+		params, _, _ := unstructured.NestedStringMap(list.Items[i].Object, "parameters")
+
+		matches := true
+		for key, value := range parameters {
+			if params[key] != value {
+				matches = false
+				break
+			}
+		}
+
+		if matches {
+			s := g.graph.Node(list.Items[i].GroupVersionKind(), &list.Items[i])
+			g.graph.Relationship(s, RelationshipReferences, n)
+		}
+	}
+
+	return nil
+}
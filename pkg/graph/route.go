@@ -15,8 +15,6 @@
 package graph
 
 import (
-	"context"
-
 	v1 "github.com/openshift/api/route/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -58,7 +56,7 @@ func (g *RouteV1Graph) Route(obj *v1.Route) (*Node, error) {
 	n := g.graph.Node(obj.GroupVersionKind(), obj)
 
 	options := metav1.GetOptions{}
-	service, err := g.graph.clientset.CoreV1().Services(obj.GetNamespace()).Get(context.TODO(), obj.Spec.To.Name, options)
+	service, err := g.graph.clientset.CoreV1().Services(obj.GetNamespace()).Get(g.graph.ctx, obj.Spec.To.Name, options)
 	if err != nil {
 		return nil, err
 	}
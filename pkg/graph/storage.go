@@ -0,0 +1,98 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// StorageGroupVersion is the group version of the storage resources.
+var StorageGroupVersion = schema.GroupVersion{Group: "storage.k8s.io", Version: "v1"}
+
+// StorageV1Graph is used to graph all storage resources.
+type StorageV1Graph struct {
+	graph *Graph
+}
+
+// NewStorageV1Graph creates a new StorageV1Graph.
+func NewStorageV1Graph(g *Graph) *StorageV1Graph {
+	return &StorageV1Graph{
+		graph: g,
+	}
+}
+
+// StorageV1 retrieves the StorageV1Graph.
+func (g *Graph) StorageV1() *StorageV1Graph {
+	return g.storageV1
+}
+
+// Unstructured adds an unstructured node to the Graph.
+func (g *StorageV1Graph) Unstructured(unstr *unstructured.Unstructured) (*Node, error) {
+	switch unstr.GetKind() {
+	case "StorageClass":
+		obj := &v1.StorageClass{}
+		if err := FromUnstructured(unstr, obj); err != nil {
+			return nil, err
+		}
+		return g.StorageClass(obj)
+	case "VolumeAttachment":
+		obj := &v1.VolumeAttachment{}
+		if err := FromUnstructured(unstr, obj); err != nil {
+			return nil, err
+		}
+		return g.VolumeAttachment(obj)
+	default:
+		return g.graph.Node(unstr.GroupVersionKind(), unstr), nil
+	}
+}
+
+// StorageClass adds a v1.StorageClass resource to the Graph, which is related to the CSIDriver of its provisioner.
+// The CSIDriver is a placeholder, if it is not part of the Graph like for in-tree provisioners.
+func (g *StorageV1Graph) StorageClass(obj *v1.StorageClass) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	if len(obj.Provisioner) != 0 {
+		driver := g.graph.Reference(v1.SchemeGroupVersion.WithKind("CSIDriver"), "", obj.Provisioner)
+		g.graph.Relationship(n, "provisioner", driver)
+	}
+
+	return n, nil
+}
+
+// VolumeAttachment adds a v1.VolumeAttachment resource to the Graph, which is related to the CSIDriver of its attacher,
+// the Node the volume is attached to and the attached PersistentVolume.
+func (g *StorageV1Graph) VolumeAttachment(obj *v1.VolumeAttachment) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	if len(obj.Spec.Attacher) != 0 {
+		driver := g.graph.Reference(v1.SchemeGroupVersion.WithKind("CSIDriver"), "", obj.Spec.Attacher)
+		g.graph.Relationship(driver, "attacher", n)
+	}
+
+	if len(obj.Spec.NodeName) != 0 {
+		node := g.graph.Reference(corev1.SchemeGroupVersion.WithKind("Node"), "", obj.Spec.NodeName)
+		g.graph.Relationship(n, "attached-to", node)
+	}
+
+	if obj.Spec.Source.PersistentVolumeName != nil {
+		pv := g.graph.Reference(corev1.SchemeGroupVersion.WithKind("PersistentVolume"), "", *obj.Spec.Source.PersistentVolumeName)
+		g.graph.Relationship(n, "attaches", pv)
+	}
+
+	return n, nil
+}
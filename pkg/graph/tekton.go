@@ -0,0 +1,138 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// TektonV1Graph is used to graph all tekton pipeline resources.
+type TektonV1Graph struct {
+	graph *Graph
+}
+
+// NewTektonV1Graph creates a new TektonV1Graph.
+func NewTektonV1Graph(g *Graph) *TektonV1Graph {
+	return &TektonV1Graph{
+		graph: g,
+	}
+}
+
+// TektonV1 retrieves the TektonV1Graph.
+func (g *Graph) TektonV1() *TektonV1Graph {
+	return g.tektonV1
+}
+
+// Unstructured adds an unstructured node to the Graph.
+func (g *TektonV1Graph) Unstructured(unstr *unstructured.Unstructured) (*Node, error) {
+	switch unstr.GetKind() {
+	case "PipelineRun":
+		return g.PipelineRun(unstr)
+	case "Pipeline":
+		return g.Pipeline(unstr)
+	case "TaskRun":
+		return g.TaskRun(unstr)
+	default:
+		return g.graph.Node(unstr.GroupVersionKind(), unstr), nil
+	}
+}
+
+// PipelineRun adds a PipelineRun resource to the Graph.
+func (g *TektonV1Graph) PipelineRun(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+	gv := obj.GroupVersionKind().GroupVersion()
+
+	if name, _, _ := unstructured.NestedString(obj.Object, "spec", "pipelineRef", "name"); len(name) != 0 {
+		p := g.graph.Reference(gv.WithKind("Pipeline"), obj.GetNamespace(), name)
+		g.graph.Relationship(n, "Pipeline", p)
+	}
+
+	children, _, _ := unstructured.NestedSlice(obj.Object, "status", "childReferences")
+	for _, child := range children {
+		ref, ok := child.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		apiVersion, _, _ := unstructured.NestedString(ref, "apiVersion")
+		kind, _, _ := unstructured.NestedString(ref, "kind")
+		name, _, _ := unstructured.NestedString(ref, "name")
+		if len(kind) == 0 || len(name) == 0 {
+			continue
+		}
+		c := g.graph.Reference(schema.FromAPIVersionAndKind(apiVersion, kind), obj.GetNamespace(), name)
+		g.graph.Relationship(n, kind, c)
+	}
+
+	return n, nil
+}
+
+// Pipeline adds a Pipeline resource to the Graph.
+func (g *TektonV1Graph) Pipeline(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	for _, field := range []string{"tasks", "finally"} {
+		tasks, _, _ := unstructured.NestedSlice(obj.Object, "spec", field)
+		for _, task := range tasks {
+			if t, ok := task.(map[string]interface{}); ok {
+				g.TaskRef(n, obj, t)
+			}
+		}
+	}
+
+	return n, nil
+}
+
+// TaskRun adds a TaskRun resource to the Graph.
+func (g *TektonV1Graph) TaskRun(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	g.TaskRef(n, obj, obj.Object["spec"])
+
+	if name, _, _ := unstructured.NestedString(obj.Object, "status", "podName"); len(name) != 0 {
+		p := g.graph.Reference(schema.FromAPIVersionAndKind(v1.GroupName, "Pod"), obj.GetNamespace(), name)
+		g.graph.Relationship(n, "Pod", p)
+	}
+
+	return n, nil
+}
+
+// TaskRef adds the Task or ClusterTask referenced by the taskRef field within spec to the Graph.
+func (g *TektonV1Graph) TaskRef(n *Node, obj *unstructured.Unstructured, spec interface{}) *Node {
+	s, ok := spec.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	name, _, _ := unstructured.NestedString(s, "taskRef", "name")
+	if len(name) == 0 {
+		return nil
+	}
+
+	kind, _, _ := unstructured.NestedString(s, "taskRef", "kind")
+	namespace := obj.GetNamespace()
+	switch kind {
+	case "ClusterTask":
+		namespace = ""
+	case "":
+		kind = "Task"
+	}
+
+	t := g.graph.Reference(obj.GroupVersionKind().GroupVersion().WithKind(kind), namespace, name)
+	g.graph.Relationship(n, kind, t)
+
+	return t
+}
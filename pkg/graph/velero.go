@@ -0,0 +1,95 @@
+// Copyright 2020 Steve Teuber
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// VeleroGroupVersion is the group version of the velero resources.
+var VeleroGroupVersion = schema.GroupVersion{Group: "velero.io", Version: "v1"}
+
+// VeleroV1Graph is used to graph all velero resources.
+type VeleroV1Graph struct {
+	graph *Graph
+}
+
+// NewVeleroV1Graph creates a new VeleroV1Graph.
+func NewVeleroV1Graph(g *Graph) *VeleroV1Graph {
+	return &VeleroV1Graph{
+		graph: g,
+	}
+}
+
+// VeleroV1 retrieves the VeleroV1Graph.
+func (g *Graph) VeleroV1() *VeleroV1Graph {
+	return g.veleroV1
+}
+
+// Unstructured adds an unstructured node to the Graph.
+func (g *VeleroV1Graph) Unstructured(unstr *unstructured.Unstructured) (*Node, error) {
+	switch unstr.GetKind() {
+	case "Backup":
+		return g.Backup(unstr)
+	case "Restore":
+		return g.Restore(unstr)
+	default:
+		return g.graph.Node(unstr.GroupVersionKind(), unstr), nil
+	}
+}
+
+// Backup adds a Backup resource to the Graph, which is related to its Schedule and storage locations.
+// Backups created by a schedule are labeled with the name of the schedule.
+func (g *VeleroV1Graph) Backup(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	if name := obj.GetLabels()["velero.io/schedule-name"]; len(name) != 0 {
+		s := g.graph.Reference(VeleroGroupVersion.WithKind("Schedule"), obj.GetNamespace(), name)
+		g.graph.Relationship(s, RelationshipOwns, n)
+	}
+
+	if name, _, _ := unstructured.NestedString(obj.Object, "spec", "storageLocation"); len(name) != 0 {
+		l := g.graph.Reference(VeleroGroupVersion.WithKind("BackupStorageLocation"), obj.GetNamespace(), name)
+		g.graph.Relationship(n, "storageLocation", l)
+	}
+
+	names, _, _ := unstructured.NestedStringSlice(obj.Object, "spec", "volumeSnapshotLocations")
+	for _, name := range names {
+		l := g.graph.Reference(VeleroGroupVersion.WithKind("VolumeSnapshotLocation"), obj.GetNamespace(), name)
+		g.graph.Relationship(n, "volumeSnapshotLocation", l)
+	}
+
+	return n, nil
+}
+
+// Restore adds a Restore resource to the Graph, which is related to the restored Backup or Schedule.
+// A backup which no longer exists stays a placeholder node, so broken restores are visible.
+// The storage locations are related through the backup, because a restore has none of its own.
+func (g *VeleroV1Graph) Restore(obj *unstructured.Unstructured) (*Node, error) {
+	n := g.graph.Node(obj.GroupVersionKind(), obj)
+
+	if name, _, _ := unstructured.NestedString(obj.Object, "spec", "backupName"); len(name) != 0 {
+		b := g.graph.Reference(VeleroGroupVersion.WithKind("Backup"), obj.GetNamespace(), name)
+		g.graph.Relationship(n, "backup", b)
+	}
+
+	if name, _, _ := unstructured.NestedString(obj.Object, "spec", "scheduleName"); len(name) != 0 {
+		s := g.graph.Reference(VeleroGroupVersion.WithKind("Schedule"), obj.GetNamespace(), name)
+		g.graph.Relationship(n, "schedule", s)
+	}
+
+	return n, nil
+}